@@ -1,3 +1,6 @@
+-- Schema of a new database, databases created by older versions are upgraded on startup
+-- by the migrations of internal/adapters/repository/migrate.go, keep both in sync
+
 CREATE TABLE AggregatedData(
     Data_id SERIAL PRIMARY KEY,
     Pair_name VARCHAR NOT NULL,
@@ -5,7 +8,18 @@ CREATE TABLE AggregatedData(
     StoredTime TimestampTZ DEFAULT NOW(),
     Average_price FLOAT NOT NULL, 
     Min_price FLOAT NOT NULL,
-    Max_price FLOAT NOT NULL
+    Max_price FLOAT NOT NULL,
+    Origin VARCHAR(10) NOT NULL DEFAULT 'live', -- live, synthetic or mixed
+    Volume FLOAT NOT NULL DEFAULT 0, -- optional feed fields, 0 means they were not sent
+    Average_bid FLOAT NOT NULL DEFAULT 0,
+    Average_ask FLOAT NOT NULL DEFAULT 0,
+    Price_sum FLOAT NOT NULL DEFAULT 0, -- components of the tick-weighted and time-weighted averages
+    Tick_count BIGINT NOT NULL DEFAULT 0,
+    Weighted_sum FLOAT NOT NULL DEFAULT 0, -- prices multiplied by the time they were held, ms
+    Weighted_time BIGINT NOT NULL DEFAULT 0,
+    Bid_sum FLOAT NOT NULL DEFAULT 0, -- components of the average bid and ask
+    Ask_sum FLOAT NOT NULL DEFAULT 0,
+    Quote_count BIGINT NOT NULL DEFAULT 0
 );
 
 CREATE TABLE LatestData(
@@ -13,6 +27,10 @@ CREATE TABLE LatestData(
     Pair_name VARCHAR NOT NULL,
     Price FLOAT NOT NULL,
     StoredTime BIGINT NOT NULL,
+    Origin VARCHAR(10) NOT NULL DEFAULT 'live',
+    Quantity FLOAT NOT NULL DEFAULT 0,
+    Bid FLOAT NOT NULL DEFAULT 0,
+    Ask FLOAT NOT NULL DEFAULT 0,
     CONSTRAINT unique_exchange_pair UNIQUE (Exchange, Pair_name)
 );
 
@@ -29,3 +47,46 @@ $$;
 CREATE TRIGGER expire_table_delete_old_rows_trigger
     AFTER INSERT ON AggregatedData
     EXECUTE PROCEDURE expire_table_delete_old_rows();
+
+-- Symbol registry changes made by the admin API and feed discovery, SYMBOLS config is used for missing symbols
+CREATE TABLE Symbols(
+    Symbol VARCHAR(20) PRIMARY KEY,
+    Active BOOLEAN NOT NULL,
+    Discovered BOOLEAN NOT NULL DEFAULT FALSE,
+    UpdatedTime TimestampTZ NOT NULL DEFAULT NOW()
+);
+
+-- OHLC candles of 1s, 1m, 5m, 15m, 1h and 1d resolutions, a bucket saved several times is merged into one row
+CREATE TABLE Candles(
+    Exchange VARCHAR(100) NOT NULL, -- exchange name or All
+    Pair_name VARCHAR NOT NULL,
+    Resolution VARCHAR(3) NOT NULL,
+    StartTime TimestampTZ NOT NULL, -- bucket start aligned to the resolution
+    Open_price FLOAT NOT NULL,
+    High_price FLOAT NOT NULL,
+    Low_price FLOAT NOT NULL,
+    Close_price FLOAT NOT NULL,
+    Ticks BIGINT NOT NULL,
+    Volume FLOAT NOT NULL DEFAULT 0,
+    Origin VARCHAR(10) NOT NULL DEFAULT 'live',
+    OpenTime BIGINT NOT NULL, -- event times of the open and close ticks, unix ms
+    CloseTime BIGINT NOT NULL,
+    PRIMARY KEY (Exchange, Pair_name, Resolution, StartTime)
+);
+
+CREATE INDEX candles_resolution_start ON Candles (Resolution, StartTime);
+
+-- 1s candles are kept for a day, minute ones for 7 weeks like AggregatedData, hourly and daily ones are kept
+CREATE FUNCTION expire_candles_delete_old_rows() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  DELETE FROM Candles WHERE Resolution = '1s' AND StartTime < NOW() - INTERVAL '1 day';
+  DELETE FROM Candles WHERE Resolution IN ('1m', '5m', '15m') AND StartTime < NOW() - INTERVAL '7 weeks';
+  RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER expire_candles_delete_old_rows_trigger
+    AFTER INSERT ON Candles
+    EXECUTE PROCEDURE expire_candles_delete_old_rows();
@@ -0,0 +1,396 @@
+// Command exchange-sim is a local exchange which streams ticks over TCP in the protocol of the live exchanges
+//
+// By default every line is {"symbol":"BTCUSDT","price":60000.5,"timestamp":1700000000000}, optionally with quantity, bid and ask,
+// -format csv and -format binary send the other wire formats of the exchange decoders, -ws serves the ticks over WebSocket.
+// Faults can be turned on to check reconnects of the live mode: dropped connections, malformed lines and stalls.
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	datafetcher "marketflow/internal/adapters/dataFetcher"
+	"marketflow/internal/domain"
+	"marketflow/internal/packages/pricemodels"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type config struct {
+	addr      string
+	symbols   []string
+	rate      float64 // ticks per second of every symbol
+	model     string
+	seed      int64
+	timeScale float64
+	quotes    bool
+	format    string
+	ws        bool
+
+	dropAfter  time.Duration // connection is closed after this time
+	malformed  float64       // share of malformed lines
+	stallEvery time.Duration // connection stops sending for stallFor with this period
+	stallFor   time.Duration
+}
+
+func main() {
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ln, err := net.Listen("tcp", cfg.addr)
+	if err != nil {
+		slog.Error("Failed to listen", "addr", cfg.addr, "error", err.Error())
+		os.Exit(1)
+	}
+	slog.Info("Exchange simulator is listening", "addr", ln.Addr().String(), "websocket", cfg.ws, "symbols", strings.Join(cfg.symbols, ","), "rate", cfg.rate, "model", cfg.model)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if cfg.ws {
+		serveWebSocket(ctx, ln, cfg)
+	} else {
+		serveTCP(ctx, ln, cfg)
+	}
+	slog.Info("Exchange simulator is stopped")
+}
+
+func serveTCP(ctx context.Context, ln net.Listener, cfg config) {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	wg := &sync.WaitGroup{}
+	for conn := 0; ; conn++ {
+		c, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Failed to accept connection", "error", err.Error())
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			defer c.Close()
+			send := func(msg []byte) error {
+				_, err := c.Write(msg)
+				return err
+			}
+			serve(ctx, c.RemoteAddr().String(), send, nil, cfg, seed)
+		}(cfg.seed + int64(conn))
+	}
+	wg.Wait()
+}
+
+// Every tick is one WebSocket message, binary format is sent in binary messages.
+// Until the client subscribes all symbols are streamed, then only the symbols named in its text messages
+func serveWebSocket(ctx context.Context, ln net.Listener, cfg config) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	wg := &sync.WaitGroup{}
+	var conns atomic.Int64
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		wg.Add(1)
+		defer wg.Done()
+		defer c.Close()
+
+		connCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		client := c.RemoteAddr().String()
+
+		subs := &subscriptions{}
+		// Reads subscriptions, pings are answered by the default handler
+		go func() {
+			defer cancel()
+			for {
+				_, msg, err := c.ReadMessage()
+				if err != nil {
+					return
+				}
+				subs.add(string(msg), cfg.symbols)
+				slog.Info("Client subscribed", "client", client, "message", string(msg))
+			}
+		}()
+
+		messageType := websocket.TextMessage
+		if cfg.format == datafetcher.DecoderBinary {
+			messageType = websocket.BinaryMessage
+		}
+		send := func(msg []byte) error {
+			c.SetWriteDeadline(time.Now().Add(5 * time.Second))
+			return c.WriteMessage(messageType, msg)
+		}
+		serve(connCtx, client, send, subs.has, cfg, cfg.seed+conns.Add(1))
+	})}
+
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Failed to serve WebSocket", "error", err.Error())
+	}
+	wg.Wait()
+}
+
+// Symbols subscribed by a WebSocket client
+type subscriptions struct {
+	mu      sync.Mutex
+	symbols map[string]bool
+}
+
+// Subscribes symbols mentioned in the message in any case
+func (s *subscriptions) add(msg string, symbols []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.symbols == nil {
+		s.symbols = make(map[string]bool)
+	}
+	for _, symbol := range symbols {
+		if strings.Contains(strings.ToUpper(msg), symbol) {
+			s.symbols[symbol] = true
+		}
+	}
+}
+
+// Every symbol is streamed until the first subscription
+func (s *subscriptions) has(symbol string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.symbols == nil || s.symbols[symbol]
+}
+
+func parseFlags(args []string) (config, error) {
+	// Own flag set: the domain package registers flags of the server
+	fs := flag.NewFlagSet("exchange-sim", flag.ContinueOnError)
+
+	cfg := config{}
+	symbols := fs.String("symbols", strings.Join(domain.DefaultSymbols, ","), "comma separated symbols")
+	fs.StringVar(&cfg.addr, "addr", ":40101", "listen address")
+	fs.Float64Var(&cfg.rate, "rate", 1, "ticks per second of every symbol")
+	fs.StringVar(&cfg.model, "model", pricemodels.GBM, "price model: uniform, gbm, ou or jump")
+	fs.Int64Var(&cfg.seed, "seed", 0, "random seed, 0 is random")
+	fs.Float64Var(&cfg.timeScale, "time-scale", 1, "simulated seconds per real second")
+	fs.BoolVar(&cfg.quotes, "quotes", false, "send quantity, bid and ask")
+	fs.BoolVar(&cfg.ws, "ws", false, "serve WebSocket instead of TCP")
+	fs.StringVar(&cfg.format, "format", datafetcher.DecoderJSON, "wire format: json, csv or binary")
+	fs.DurationVar(&cfg.dropAfter, "drop-after", 0, "close every connection after this time, 0 keeps it open")
+	fs.Float64Var(&cfg.malformed, "malformed", 0, "share of malformed lines from 0 to 1")
+	fs.DurationVar(&cfg.stallEvery, "stall-every", 0, "stop sending with this period, 0 never stalls")
+	fs.DurationVar(&cfg.stallFor, "stall-for", 15*time.Second, "length of a stall")
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	for _, symbol := range strings.Split(*symbols, ",") {
+		if symbol = strings.TrimSpace(symbol); symbol != "" {
+			cfg.symbols = append(cfg.symbols, symbol)
+		}
+	}
+
+	switch {
+	case len(cfg.symbols) == 0:
+		return cfg, errors.New("no symbols")
+	case cfg.rate <= 0:
+		return cfg, errors.New("rate must be positive")
+	case cfg.malformed < 0 || cfg.malformed > 1:
+		return cfg, errors.New("malformed must be from 0 to 1")
+	case cfg.timeScale <= 0:
+		return cfg, errors.New("time-scale must be positive")
+	case cfg.format != datafetcher.DecoderJSON && cfg.format != datafetcher.DecoderCSV && cfg.format != datafetcher.DecoderBinary:
+		return cfg, errors.New("format must be json, csv or binary")
+	}
+	if _, err := pricemodels.New(cfg.model, pricemodels.Params{}); err != nil {
+		return cfg, err
+	}
+	if cfg.seed == 0 {
+		cfg.seed = time.Now().UnixNano()
+	}
+	return cfg, nil
+}
+
+// Streams ticks to one client until it disconnects, the simulator stops or the connection is dropped,
+// ticks of the symbols rejected by subscribed are skipped (nil streams everything)
+func serve(ctx context.Context, client string, send func(msg []byte) error, subscribed func(symbol string) bool, cfg config, seed int64) {
+	slog.Info("Client connected", "client", client)
+
+	rng := rand.New(rand.NewSource(seed))
+	params := datafetcher.LoadTestModelConfig(cfg.symbols).Params
+	models := make(map[string]pricemodels.Model, len(cfg.symbols))
+	prices := make(map[string]float64, len(cfg.symbols))
+	for _, symbol := range cfg.symbols {
+		models[symbol], _ = pricemodels.New(cfg.model, params[symbol])
+		prices[symbol] = params[symbol].Base
+	}
+
+	var drop <-chan time.Time
+	if cfg.dropAfter != 0 {
+		drop = time.After(cfg.dropAfter)
+	}
+	var stall <-chan time.Time
+	if cfg.stallEvery != 0 {
+		t := time.NewTicker(cfg.stallEvery)
+		defer t.Stop()
+		stall = t.C
+	}
+
+	// Every symbol gets rate ticks per second
+	interval := time.Duration(float64(time.Second) / (cfg.rate * float64(len(cfg.symbols))))
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for i := 0; ; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-drop:
+			slog.Info("Dropping connection", "client", client)
+			return
+		case <-stall:
+			slog.Info("Stalling connection", "client", client, "for", cfg.stallFor.String())
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(cfg.stallFor):
+			}
+		case now := <-t.C:
+			symbol := cfg.symbols[i%len(cfg.symbols)]
+			dt := interval.Seconds() * float64(len(cfg.symbols)) * cfg.timeScale
+			prices[symbol] = models[symbol].Next(prices[symbol], dt, rng)
+
+			if subscribed != nil && !subscribed(symbol) {
+				continue
+			}
+
+			tick := newTick(symbol, prices[symbol], now, cfg.quotes, rng)
+			if err := send(encodeTick(cfg.format, tick, rng.Float64() < cfg.malformed, rng)); err != nil {
+				slog.Info("Client disconnected", "client", client, "error", err.Error())
+				return
+			}
+		}
+	}
+}
+
+// Line of the live exchanges protocol
+type tick struct {
+	Symbol    string  `json:"symbol"`
+	Price     float64 `json:"price"`
+	Timestamp int64   `json:"timestamp"`
+	Quantity  float64 `json:"quantity,omitempty"`
+	Bid       float64 `json:"bid,omitempty"`
+	Ask       float64 `json:"ask,omitempty"`
+}
+
+// Returns framed tick in the wire format, malformed ticks are broken but keep the framing
+func encodeTick(format string, tick tick, malformed bool, rng *rand.Rand) []byte {
+	switch format {
+	case datafetcher.DecoderCSV:
+		line := tick.csvLine()
+		if malformed {
+			line = malformedCSVLine(line, rng)
+		}
+		return append(line, '\n')
+	case datafetcher.DecoderBinary:
+		payload := tick.binaryPayload()
+		if malformed {
+			payload = payload[:rng.Intn(len(payload))] // truncated payload in a valid frame
+		}
+		return append(binary.BigEndian.AppendUint16(nil, uint16(len(payload))), payload...)
+	default:
+		line, _ := json.Marshal(tick)
+		if malformed {
+			line = malformedLine(line, rng)
+		}
+		return append(line, '\n')
+	}
+}
+
+func newTick(symbol string, price float64, now time.Time, quotes bool, rng *rand.Rand) tick {
+	tick := tick{Symbol: symbol, Price: price, Timestamp: now.UnixMilli()}
+	if quotes {
+		tick.Quantity = rng.ExpFloat64()
+		tick.Bid = price * 0.9999
+		tick.Ask = price * 1.0001
+	}
+	return tick
+}
+
+// symbol,price,timestamp[,quantity,bid,ask]
+func (t tick) csvLine() []byte {
+	line := []byte(t.Symbol)
+	line = append(line, ',')
+	line = strconv.AppendFloat(line, t.Price, 'f', -1, 64)
+	line = append(line, ',')
+	line = strconv.AppendInt(line, t.Timestamp, 10)
+	if t.Quantity != 0 {
+		for _, val := range []float64{t.Quantity, t.Bid, t.Ask} {
+			line = append(line, ',')
+			line = strconv.AppendFloat(line, val, 'f', -1, 64)
+		}
+	}
+	return line
+}
+
+// Payload of the binary decoder frame: symbol length, symbol, price, timestamp and optional quantity, bid, ask
+func (t tick) binaryPayload() []byte {
+	payload := append([]byte{byte(len(t.Symbol))}, t.Symbol...)
+	payload = binary.BigEndian.AppendUint64(payload, math.Float64bits(t.Price))
+	payload = binary.BigEndian.AppendUint64(payload, uint64(t.Timestamp))
+	if t.Quantity != 0 {
+		for _, val := range []float64{t.Quantity, t.Bid, t.Ask} {
+			payload = binary.BigEndian.AppendUint64(payload, math.Float64bits(val))
+		}
+	}
+	return payload
+}
+
+// Returns one of the broken variants of the line
+func malformedLine(line []byte, rng *rand.Rand) []byte {
+	switch rng.Intn(4) {
+	case 0:
+		return line[:rng.Intn(len(line))] // truncated JSON
+	case 1:
+		return []byte(strings.Replace(string(line), `"price":`, `"price":"`, 1)) // broken string
+	case 2:
+		return []byte(`{"symbol":"BTCUSDT","price":"not a number"}`)
+	}
+	return []byte("garbage line")
+}
+
+// Returns one of the broken variants of the CSV line
+func malformedCSVLine(line []byte, rng *rand.Rand) []byte {
+	switch rng.Intn(3) {
+	case 0:
+		return line[:rng.Intn(len(line))] // truncated line
+	case 1:
+		return []byte("BTCUSDT,not a number,1700000000000")
+	}
+	return []byte("garbage line")
+}
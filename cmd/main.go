@@ -5,8 +5,11 @@ import (
 	"log"
 	"log/slog"
 	cache "marketflow/internal/adapters/cacheMemory"
+	"marketflow/internal/adapters/capture"
 	datafetcher "marketflow/internal/adapters/dataFetcher"
+	"marketflow/internal/adapters/quarantine"
 	"marketflow/internal/adapters/repository"
+	"marketflow/internal/adapters/symbols"
 	"marketflow/internal/app"
 	"marketflow/internal/domain"
 	"marketflow/internal/service"
@@ -33,8 +36,12 @@ func main() {
 func setupApp() (*http.Server, func()) {
 	cacheMemory := cache.ConnectCacheMemory()
 	repo := repository.ConnectDB()
-	datafetch := datafetcher.NewLiveModeFetcher()
-	datafetchServ := service.NewDataFetcher(datafetch, repo, cacheMemory)
+	recorder := capture.NewFileRecorder()
+	ticksQuarantine := quarantine.NewMemoryQuarantine()
+	symbolRegistry := symbols.NewRegistry(repo)
+	pushIngest := datafetcher.NewPushIngest(datafetcher.LoadIngestConfig())
+	datafetch := datafetcher.NewLiveModeFetcher(recorder, ticksQuarantine, symbolRegistry, pushIngest)
+	datafetchServ := service.NewDataFetcher(datafetch, repo, cacheMemory, recorder, ticksQuarantine, symbolRegistry, pushIngest)
 
 	if err := datafetchServ.ListenAndSave(); err != nil {
 		slog.Error("Failed to start data fetcher", "error", err)
@@ -47,10 +54,15 @@ func setupApp() (*http.Server, func()) {
 		Addr:    ":" + *domain.Port,
 		Handler: router,
 	}
+	// Price streams never end by themselves
+	srv.RegisterOnShutdown(datafetchServ.CloseStreams)
 
 	cleanup := func() {
 		slog.Info("Cleaning up resources...")
 		datafetchServ.StopListening()
+		if recorder.Status().Enabled {
+			recorder.Stop()
+		}
 		cacheMemory.Cache.Close()
 		repo.Db.Close()
 	}
@@ -3,6 +3,7 @@ module marketflow
 go 1.23
 
 require (
+	github.com/gorilla/websocket v1.5.3
 	github.com/lib/pq v1.10.9
 	github.com/redis/go-redis/v9 v9.8.0
 )
@@ -46,3 +46,16 @@ func (c *RedisCacheMemory) SaveLatestData(latestData map[string]domain.Data) err
 
 	return nil
 }
+
+// Removes keys of the symbol for every exchange:
+// "latest {Exchange} {Symbol}" and "{Exchange} {Symbol}"
+func (c *RedisCacheMemory) DeleteSymbol(symbol string) error {
+	keys := make([]string, 0, 2*len(domain.Exchanges))
+	for _, exchange := range domain.Exchanges {
+		keys = append(keys, "latest "+exchange+" "+symbol, exchange+" "+symbol)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	return c.Cache.Del(ctx, keys...).Err()
+}
@@ -0,0 +1,254 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"marketflow/internal/domain"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FileRecorder writes raw exchange ticks to append-only NDJSON files
+//
+// The current file is rotated when it grows over maxSize bytes or gets older than maxAge.
+// File names: {dir}/ticks-{YYYYMMDD-HHMMSS}.ndjson, they can be played back by the replay mode
+type FileRecorder struct {
+	dir     string
+	maxSize int64
+	maxAge  time.Duration
+
+	enabled atomic.Bool
+
+	mu        sync.Mutex
+	file      *os.File
+	writer    *bufio.Writer
+	fileName  string
+	openedAt  time.Time
+	size      int64
+	records   int64
+	bytes     int64
+	startedAt time.Time
+	stopFlush chan struct{}
+}
+
+var _ domain.TickRecorder = (*FileRecorder)(nil)
+
+// NewFileRecorder reads capture configs:
+//   - CAPTURE_DIR : directory for capture files (default "captures")
+//   - CAPTURE_MAX_SIZE_MB : file size limit before rotation (default 100)
+//   - CAPTURE_ROTATE_INTERVAL : file age limit before rotation (default 1h)
+//
+// Capturing is started with CAPTURE_ENABLED=true or later by Start
+func NewFileRecorder() *FileRecorder {
+	rec := &FileRecorder{
+		dir:     "captures",
+		maxSize: 100 << 20,
+		maxAge:  time.Hour,
+	}
+
+	if dir := os.Getenv("CAPTURE_DIR"); dir != "" {
+		rec.dir = dir
+	}
+
+	if size := os.Getenv("CAPTURE_MAX_SIZE_MB"); size != "" {
+		if mb, err := strconv.Atoi(size); err == nil && mb > 0 {
+			rec.maxSize = int64(mb) << 20
+		} else {
+			slog.Warn("Invalid CAPTURE_MAX_SIZE_MB value, using default", "value", size)
+		}
+	}
+
+	if interval := os.Getenv("CAPTURE_ROTATE_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil && d > 0 {
+			rec.maxAge = d
+		} else {
+			slog.Warn("Invalid CAPTURE_ROTATE_INTERVAL value, using default", "value", interval)
+		}
+	}
+
+	if os.Getenv("CAPTURE_ENABLED") == "true" {
+		if err := rec.Start(); err != nil {
+			slog.Error("Failed to start ticks capture", "error", err.Error())
+		}
+	}
+
+	return rec
+}
+
+// Start opens a new capture file and enables recording
+func (rec *FileRecorder) Start() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if rec.enabled.Load() {
+		return errors.New("capture is already enabled")
+	}
+
+	if err := os.MkdirAll(rec.dir, 0o755); err != nil {
+		return err
+	}
+
+	if err := rec.openFile(); err != nil {
+		return err
+	}
+
+	rec.records, rec.bytes = 0, 0
+	rec.startedAt = time.Now()
+	rec.stopFlush = make(chan struct{})
+	go rec.flushLoop(rec.stopFlush)
+
+	rec.enabled.Store(true)
+	slog.Info("Ticks capture started", "file", rec.fileName)
+	return nil
+}
+
+// Stop disables recording and closes the current capture file
+func (rec *FileRecorder) Stop() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if !rec.enabled.Load() {
+		return errors.New("capture is not enabled")
+	}
+
+	rec.enabled.Store(false)
+	close(rec.stopFlush)
+
+	slog.Info("Ticks capture stopped", "file", rec.fileName, "records", rec.records)
+	return rec.closeFile()
+}
+
+func (rec *FileRecorder) Status() domain.CaptureStatus {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	status := domain.CaptureStatus{
+		Enabled: rec.enabled.Load(),
+		Records: rec.records,
+		Bytes:   rec.bytes,
+	}
+	if status.Enabled {
+		status.File = rec.fileName
+		status.StartedAt = rec.startedAt
+	}
+	return status
+}
+
+// Record appends tick line to the current capture file, it does nothing while capture is disabled
+func (rec *FileRecorder) Record(data domain.Data, raw string, receivedAt time.Time) {
+	if !rec.enabled.Load() {
+		return
+	}
+
+	line, err := json.Marshal(domain.CapturedTick{Data: data, ReceivedAt: receivedAt.UnixMilli(), Raw: raw})
+	if err != nil {
+		slog.Debug("Failed to marshal captured tick", "error", err.Error())
+		return
+	}
+	line = append(line, '\n')
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	// Capture could be stopped while waiting for the lock
+	if rec.writer == nil {
+		return
+	}
+
+	if rec.size+int64(len(line)) > rec.maxSize || time.Since(rec.openedAt) > rec.maxAge {
+		if err := rec.rotate(); err != nil {
+			slog.Error("Failed to rotate capture file, capture is stopped", "error", err.Error())
+			rec.enabled.Store(false)
+			close(rec.stopFlush)
+			return
+		}
+	}
+
+	n, err := rec.writer.Write(line)
+	if err != nil {
+		slog.Error("Failed to write captured tick", "file", rec.fileName, "error", err.Error())
+		return
+	}
+	rec.size += int64(n)
+	rec.bytes += int64(n)
+	rec.records++
+}
+
+// Flushes buffered lines every second, so the file can be read while capture is running
+func (rec *FileRecorder) flushLoop(stop chan struct{}) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			rec.mu.Lock()
+			if rec.writer != nil {
+				if err := rec.writer.Flush(); err != nil {
+					slog.Error("Failed to flush capture file", "file", rec.fileName, "error", err.Error())
+				}
+			}
+			rec.mu.Unlock()
+		}
+	}
+}
+
+// Should be called under the lock
+func (rec *FileRecorder) rotate() error {
+	if err := rec.closeFile(); err != nil {
+		return err
+	}
+	slog.Info("Rotating capture file", "records", rec.records)
+	return rec.openFile()
+}
+
+// Should be called under the lock
+func (rec *FileRecorder) openFile() error {
+	now := time.Now()
+	name := filepath.Join(rec.dir, "ticks-"+now.Format("20060102-150405")+".ndjson")
+
+	// Several rotations in one second must not write to the same file
+	for i := 1; ; i++ {
+		if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		name = filepath.Join(rec.dir, fmt.Sprintf("ticks-%s-%d.ndjson", now.Format("20060102-150405"), i))
+	}
+
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	rec.file = file
+	rec.writer = bufio.NewWriterSize(file, 64*1024)
+	rec.fileName = name
+	rec.openedAt = now
+	rec.size = 0
+	return nil
+}
+
+// Should be called under the lock
+func (rec *FileRecorder) closeFile() error {
+	if rec.file == nil {
+		return nil
+	}
+
+	flushErr := rec.writer.Flush()
+	closeErr := rec.file.Close()
+	rec.file, rec.writer = nil, nil
+
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
@@ -0,0 +1,461 @@
+package datafetcher
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"marketflow/internal/domain"
+	"marketflow/internal/packages/pricemodels"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadExchangeConfigs reads the exchange list from the environment
+//
+// Config structure:
+//   - EXCHANGES : comma separated exchange names (e.g. "Exchange1,Exchange2,Exchange3")
+//   - {NAME}_HOST, {NAME}_PORT : address of every listed exchange, NAME is upper cased
+//   - {NAME}_NAME is accepted as host for old configs
+//   - {NAME}_URL : ws:// or wss:// feed, it is used instead of host and port
+//   - {NAME}_SUBSCRIBE : WebSocket subscription message, sent per active symbol if it has {symbol} or {symbol_lower}
+//   - {NAME}_PING_INTERVAL : WebSocket keepalive period (default 20s)
+//   - {NAME}_SYMBOL_ALIASES : native to canonical symbols (e.g. "XBTUSDT=BTCUSDT,BTC-PERP=BTCUSDT")
+//   - {NAME}_DECODER : wire format of the exchange (json, csv or binary), json by default
+//
+// Without EXCHANGES the numbered EXCHANGE1.., EXCHANGE2.. variables are read until the first missing port
+func LoadExchangeConfigs() []domain.ExchangeConfig {
+	names := make([]string, 0)
+	if list := os.Getenv("EXCHANGES"); list != "" {
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	} else {
+		for i := 1; os.Getenv("EXCHANGE"+strconv.Itoa(i)+"_PORT") != ""; i++ {
+			names = append(names, "Exchange"+strconv.Itoa(i))
+		}
+	}
+
+	configs := make([]domain.ExchangeConfig, 0, len(names))
+	for _, name := range names {
+		prefix := strings.ToUpper(name)
+
+		host := os.Getenv(prefix + "_HOST")
+		if host == "" {
+			host = os.Getenv(prefix + "_NAME")
+		}
+		port := os.Getenv(prefix + "_PORT")
+		url := os.Getenv(prefix + "_URL")
+		if url != "" && !strings.HasPrefix(url, "ws://") && !strings.HasPrefix(url, "wss://") {
+			slog.Warn("Exchange URL must be ws:// or wss://, skipping", "exchange", name, "url", url)
+			continue
+		}
+		if port == "" && url == "" {
+			slog.Warn("Exchange port is not configured, skipping", "exchange", name)
+			continue
+		}
+
+		aliases, natives := parseAliases(name, os.Getenv(prefix+"_SYMBOL_ALIASES"))
+		configs = append(configs, domain.ExchangeConfig{
+			Name:          name,
+			Host:          host,
+			Port:          port,
+			URL:           url,
+			Subscribe:     os.Getenv(prefix + "_SUBSCRIBE"),
+			PingInterval:  durationEnv(prefix+"_PING_INTERVAL", 20*time.Second),
+			SymbolAliases: aliases,
+			SymbolNatives: natives,
+			Decoder:       strings.ToLower(strings.TrimSpace(os.Getenv(prefix + "_DECODER"))),
+		})
+	}
+
+	return configs
+}
+
+// Parses "NATIVE=CANONICAL,..." list, native symbols are upper cased
+//
+// Returns native -> canonical aliases and canonical -> native symbols, if several native symbols
+// have the same canonical one, the first of them is subscribed
+func parseAliases(exchange, list string) (map[string]string, map[string]string) {
+	aliases := make(map[string]string)
+	natives := make(map[string]string)
+	for _, pair := range strings.Split(list, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		native, canonical, ok := strings.Cut(pair, "=")
+		native, canonical = strings.TrimSpace(native), strings.TrimSpace(canonical)
+		if !ok || native == "" || canonical == "" {
+			slog.Warn("Invalid symbol alias, skipping", "exchange", exchange, "alias", pair)
+			continue
+		}
+		native, canonical = strings.ToUpper(native), strings.ToUpper(canonical)
+		aliases[native] = canonical
+		if _, ok := natives[canonical]; !ok {
+			natives[canonical] = native
+		}
+	}
+	return aliases, natives
+}
+
+// ExchangeNames returns names of the configured exchanges
+func ExchangeNames(configs []domain.ExchangeConfig) []string {
+	names := make([]string, 0, len(configs))
+	for _, cfg := range configs {
+		names = append(names, cfg.Name)
+	}
+	return names
+}
+
+// Reconnect settings of live exchanges
+type BackoffConfig struct {
+	Min        time.Duration // delay before the first retry
+	Max        time.Duration // delay limit
+	MaxRetries int           // retries in a row before giving up, 0 means unlimited
+}
+
+// LoadBackoffConfig reads EXCHANGE_BACKOFF_MIN, EXCHANGE_BACKOFF_MAX and EXCHANGE_MAX_RETRIES
+func LoadBackoffConfig() BackoffConfig {
+	cfg := BackoffConfig{Min: time.Second, Max: 30 * time.Second}
+
+	cfg.Min = durationEnv("EXCHANGE_BACKOFF_MIN", cfg.Min)
+	cfg.Max = durationEnv("EXCHANGE_BACKOFF_MAX", cfg.Max)
+	if cfg.Max < cfg.Min {
+		cfg.Max = cfg.Min
+	}
+
+	if retries := os.Getenv("EXCHANGE_MAX_RETRIES"); retries != "" {
+		if n, err := strconv.Atoi(retries); err == nil && n >= 0 {
+			cfg.MaxRetries = n
+		} else {
+			slog.Warn("Invalid EXCHANGE_MAX_RETRIES value, retries are unlimited", "value", retries)
+		}
+	}
+
+	return cfg
+}
+
+// Delay before the retry number attempt (starting from 1): exponential growth with jitter
+func (cfg BackoffConfig) Delay(attempt int) time.Duration {
+	delay := cfg.Min
+	for i := 1; i < attempt && delay < cfg.Max; i++ {
+		delay *= 2
+	}
+	if delay > cfg.Max {
+		delay = cfg.Max
+	}
+
+	// Random delay in [delay/2, delay] to spread reconnects of different exchanges
+	half := int64(delay / 2)
+	if half == 0 {
+		return delay
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// Reads positive duration variable, returns def if it is missing or invalid
+func durationEnv(key string, def time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		slog.Warn("Invalid duration value, using default", "key", key, "value", val, "default", def)
+		return def
+	}
+	return d
+}
+
+// LoadStaleAfter reads EXCHANGE_STALE_AFTER: silence period after which a feed is stale (default 10s, 0 disables the watchdog)
+func LoadStaleAfter() time.Duration {
+	if os.Getenv("EXCHANGE_STALE_AFTER") == "0" {
+		return 0
+	}
+	return durationEnv("EXCHANGE_STALE_AFTER", 10*time.Second)
+}
+
+// Late ticks policies
+const (
+	LateMerge = "merge" // late ticks within allowed lateness are aggregated into a correction of their window
+	LateDrop  = "drop"  // late ticks are counted and dropped
+	LateSide  = "side"  // late ticks are kept in the quarantine side output
+)
+
+// Event-time aggregation settings
+type WindowConfig struct {
+	Size            time.Duration // window length
+	WatermarkDelay  time.Duration // out-of-order ticks tolerance before a window is closed
+	AllowedLateness time.Duration // how long a closed window accepts late ticks in merge policy
+	IdleTimeout     time.Duration // silent exchanges do not hold the watermark back after this period
+	LatePolicy      string
+}
+
+// LoadWindowConfig reads AGGREGATE_WINDOW, WATERMARK_DELAY, ALLOWED_LATENESS, WATERMARK_IDLE_TIMEOUT and LATE_TICKS_POLICY
+func LoadWindowConfig() WindowConfig {
+	cfg := WindowConfig{
+		Size:            durationEnv("AGGREGATE_WINDOW", time.Second),
+		WatermarkDelay:  durationEnv("WATERMARK_DELAY", 2*time.Second),
+		AllowedLateness: durationEnv("ALLOWED_LATENESS", 5*time.Second),
+		IdleTimeout:     durationEnv("WATERMARK_IDLE_TIMEOUT", 5*time.Second),
+		LatePolicy:      LateMerge,
+	}
+
+	// Windows are aligned to whole milliseconds of the event time
+	if cfg.Size < time.Millisecond {
+		slog.Warn("AGGREGATE_WINDOW is shorter than 1ms, using default", "value", cfg.Size.String(), "default", time.Second.String())
+		cfg.Size = time.Second
+	}
+
+	switch policy := os.Getenv("LATE_TICKS_POLICY"); policy {
+	case "":
+	case LateMerge, LateDrop, LateSide:
+		cfg.LatePolicy = policy
+	default:
+		slog.Warn("Invalid LATE_TICKS_POLICY value, using default", "value", policy, "default", cfg.LatePolicy)
+	}
+
+	return cfg
+}
+
+// Incoming ticks validation settings
+type ValidatorConfig struct {
+	MaxDeviation float64       // allowed deviation from the cross-exchange median, fraction of the median
+	Window       time.Duration // rolling median period
+	MinSamples   int           // median is not used until the symbol has enough ticks in the window
+	MaxSkew      time.Duration // ticks timestamped further ahead of the local clock are rejected
+	Quarantine   bool          // keep rejected ticks in the quarantine, otherwise only count them
+}
+
+// Limits of the ticks pushed by every source to POST /ingest
+type IngestConfig struct {
+	Rate  float64 // ticks per second
+	Burst int     // ticks at once, it is also the batch size limit
+}
+
+// LoadIngestConfig reads INGEST_RATE (default 100) and INGEST_BURST (default 1000)
+func LoadIngestConfig() IngestConfig {
+	cfg := IngestConfig{Rate: 100, Burst: 1000}
+
+	if val := os.Getenv("INGEST_RATE"); val != "" {
+		if rate, err := strconv.ParseFloat(val, 64); err == nil && rate > 0 {
+			cfg.Rate = rate
+		} else {
+			slog.Warn("Invalid INGEST_RATE value, using default", "value", val)
+		}
+	}
+
+	if val := os.Getenv("INGEST_BURST"); val != "" {
+		if burst, err := strconv.Atoi(val); err == nil && burst > 0 {
+			cfg.Burst = burst
+		} else {
+			slog.Warn("Invalid INGEST_BURST value, using default", "value", val)
+		}
+	}
+	return cfg
+}
+
+// LoadValidatorConfig reads OUTLIER_MAX_DEVIATION_PCT, OUTLIER_WINDOW, OUTLIER_MIN_SAMPLES and REJECTED_TICKS_ACTION (quarantine or drop)
+func LoadValidatorConfig() ValidatorConfig {
+	cfg := ValidatorConfig{
+		MaxDeviation: 0.2,
+		Window:       durationEnv("OUTLIER_WINDOW", 30*time.Second),
+		MinSamples:   10,
+		MaxSkew:      durationEnv("TICK_MAX_CLOCK_SKEW", 5*time.Second),
+		Quarantine:   true,
+	}
+
+	if val := os.Getenv("OUTLIER_MAX_DEVIATION_PCT"); val != "" {
+		if pct, err := strconv.ParseFloat(val, 64); err == nil && pct > 0 {
+			cfg.MaxDeviation = pct / 100
+		} else {
+			slog.Warn("Invalid OUTLIER_MAX_DEVIATION_PCT value, using default", "value", val)
+		}
+	}
+
+	if val := os.Getenv("OUTLIER_MIN_SAMPLES"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			cfg.MinSamples = n
+		} else {
+			slog.Warn("Invalid OUTLIER_MIN_SAMPLES value, using default", "value", val)
+		}
+	}
+
+	switch action := os.Getenv("REJECTED_TICKS_ACTION"); action {
+	case "", "quarantine":
+	case "drop":
+		cfg.Quarantine = false
+	default:
+		slog.Warn("Invalid REJECTED_TICKS_ACTION value, using quarantine", "value", action)
+	}
+
+	return cfg
+}
+
+// Tick identity fields for the de-duplication
+const (
+	FieldExchange  = "exchange"
+	FieldSymbol    = "symbol"
+	FieldPrice     = "price"
+	FieldTimestamp = "timestamp"
+)
+
+// Ticks de-duplication settings
+type DedupConfig struct {
+	Fields     []string      // ticks with equal fields are duplicates
+	Window     time.Duration // how long a tick identity is remembered
+	MaxEntries int           // limit of remembered identities
+}
+
+// LoadDedupConfig reads DEDUP_FIELDS (default "exchange,symbol,price,timestamp"), DEDUP_WINDOW and DEDUP_MAX_ENTRIES,
+// DEDUP_WINDOW=0 disables the de-duplication
+func LoadDedupConfig() DedupConfig {
+	cfg := DedupConfig{
+		Fields:     []string{FieldExchange, FieldSymbol, FieldPrice, FieldTimestamp},
+		Window:     durationEnv("DEDUP_WINDOW", 30*time.Second),
+		MaxEntries: 100000,
+	}
+	if os.Getenv("DEDUP_WINDOW") == "0" {
+		cfg.Window = 0
+	}
+
+	if val := os.Getenv("DEDUP_FIELDS"); val != "" {
+		fields := make([]string, 0, 4)
+		for _, field := range strings.Split(val, ",") {
+			switch field = strings.TrimSpace(field); field {
+			case FieldExchange, FieldSymbol, FieldPrice, FieldTimestamp:
+				fields = append(fields, field)
+			default:
+				slog.Warn("Unknown DEDUP_FIELDS field is ignored", "field", field)
+			}
+		}
+		if len(fields) != 0 {
+			cfg.Fields = fields
+		}
+	}
+
+	if val := os.Getenv("DEDUP_MAX_ENTRIES"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			cfg.MaxEntries = n
+		} else {
+			slog.Warn("Invalid DEDUP_MAX_ENTRIES value, using default", "value", val)
+		}
+	}
+
+	return cfg
+}
+
+// Synthetic market settings of the test mode
+type TestModelConfig struct {
+	Model     string                        // price model name
+	Seed      int64                         // random seed, the same seed gives the same prices
+	TimeScale float64                       // simulated seconds per real second
+	Params    map[string]pricemodels.Params // by symbol
+}
+
+// Default synthetic parameters of the known symbols
+var defaultModelParams = map[string]pricemodels.Params{
+	domain.BTCUSDT:  {Base: 60000.0, Volatility: 0.6, Reversion: 2, JumpRate: 20, JumpStd: 0.03},
+	domain.DOGEUSDT: {Base: 0.15, Volatility: 1.0, Reversion: 2, JumpRate: 30, JumpStd: 0.06},
+	domain.TONUSDT:  {Base: 5.0, Volatility: 0.8, Reversion: 2, JumpRate: 25, JumpStd: 0.05},
+	domain.SOLUSDT:  {Base: 150.0, Volatility: 0.9, Reversion: 2, JumpRate: 25, JumpStd: 0.05},
+	domain.ETHUSDT:  {Base: 3000.0, Volatility: 0.7, Reversion: 2, JumpRate: 20, JumpStd: 0.04},
+}
+
+// LoadTestModelConfig reads TEST_PRICE_MODEL (uniform, gbm, ou, jump), TEST_SEED (0 means random), TEST_TIME_SCALE
+// and per symbol overrides TEST_{SYMBOL}_{BASE|DRIFT|VOLATILITY|REVERSION|JUMP_RATE|JUMP_MEAN|JUMP_STD}
+func LoadTestModelConfig(symbols []string) TestModelConfig {
+	cfg := TestModelConfig{
+		Model:     pricemodels.Uniform,
+		Seed:      time.Now().UnixNano(),
+		TimeScale: 1,
+		Params:    make(map[string]pricemodels.Params, len(symbols)),
+	}
+
+	if model := os.Getenv("TEST_PRICE_MODEL"); model != "" {
+		cfg.Model = model
+	}
+
+	if val := os.Getenv("TEST_SEED"); val != "" && val != "0" {
+		if seed, err := strconv.ParseInt(val, 10, 64); err == nil {
+			cfg.Seed = seed
+		} else {
+			slog.Warn("Invalid TEST_SEED value, using random seed", "value", val)
+		}
+	}
+
+	cfg.TimeScale = floatEnv("TEST_TIME_SCALE", cfg.TimeScale)
+	if cfg.TimeScale <= 0 {
+		cfg.TimeScale = 1
+	}
+
+	for _, symbol := range symbols {
+		cfg.Params[symbol] = modelParams(symbol)
+	}
+
+	return cfg
+}
+
+// Returns model parameters of the symbol: defaults of the known pairs with TEST_{SYMBOL}_* overrides
+func modelParams(symbol string) pricemodels.Params {
+	params, ok := defaultModelParams[symbol]
+	if !ok {
+		params = pricemodels.Params{Base: 1, Volatility: 0.8, Reversion: 2, JumpRate: 25, JumpStd: 0.05}
+	}
+
+	prefix := "TEST_" + strings.ToUpper(symbol) + "_"
+	params.Base = floatEnv(prefix+"BASE", params.Base)
+	params.Drift = floatEnv(prefix+"DRIFT", params.Drift)
+	params.Volatility = floatEnv(prefix+"VOLATILITY", params.Volatility)
+	params.Reversion = floatEnv(prefix+"REVERSION", params.Reversion)
+	params.JumpRate = floatEnv(prefix+"JUMP_RATE", params.JumpRate)
+	params.JumpMean = floatEnv(prefix+"JUMP_MEAN", params.JumpMean)
+	params.JumpStd = floatEnv(prefix+"JUMP_STD", params.JumpStd)
+	return params
+}
+
+// Reads float variable, returns def if it is missing or invalid
+func floatEnv(key string, def float64) float64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		slog.Warn("Invalid number value, using default", "key", key, "value", val, "default", def)
+		return def
+	}
+	return f
+}
+
+// Returns path of the named file inside the directory from dirKey variable (current directory by default),
+// the name can't leave the directory
+func resolveFile(dirKey, name string) (string, error) {
+	if name == "" {
+		return "", errors.New("file is not specified")
+	}
+
+	dir := os.Getenv(dirKey)
+	if dir == "" {
+		dir = "."
+	}
+
+	path := filepath.Join(dir, filepath.Clean("/"+name))
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", name)
+	}
+	return path, nil
+}
@@ -0,0 +1,169 @@
+package datafetcher
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"marketflow/internal/domain"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Decoder reads messages of one exchange wire format
+//
+// A new format only needs a Decoder registered with RegisterDecoder
+// and selected by the {NAME}_DECODER variable of the exchange
+type Decoder interface {
+	// Split frames messages in the connection stream, it is used as bufio.SplitFunc
+	Split(data []byte, atEOF bool) (advance int, token []byte, err error)
+	// Decode parses one message, exchange name is assigned by the worker
+	Decode(msg []byte) (domain.Data, error)
+}
+
+// Built-in decoders
+const (
+	DecoderJSON   = "json"   // {"symbol":"BTCUSDT","price":60000.5,"timestamp":1700000000000} lines
+	DecoderCSV    = "csv"    // symbol,price,timestamp[,quantity,bid,ask] lines
+	DecoderBinary = "binary" // length-prefixed frames, see binaryDecoder
+)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]func() Decoder{
+		DecoderJSON:   func() Decoder { return jsonDecoder{} },
+		DecoderCSV:    func() Decoder { return csvDecoder{} },
+		DecoderBinary: func() Decoder { return binaryDecoder{} },
+	}
+)
+
+// RegisterDecoder adds decoder of a new wire format, every exchange gets its own instance
+func RegisterDecoder(name string, factory func() Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[name] = factory
+}
+
+// NewDecoder returns decoder by its name, empty name is JSON
+func NewDecoder(name string) (Decoder, error) {
+	if name == "" {
+		name = DecoderJSON
+	}
+
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	factory, ok := decoders[name]
+	if !ok {
+		names := make([]string, 0, len(decoders))
+		for known := range decoders {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown decoder %q, must be one of (%s)", name, strings.Join(names, ", "))
+	}
+	return factory(), nil
+}
+
+type jsonDecoder struct{}
+
+func (jsonDecoder) Split(data []byte, atEOF bool) (int, []byte, error) {
+	return bufio.ScanLines(data, atEOF)
+}
+
+func (jsonDecoder) Decode(msg []byte) (domain.Data, error) {
+	data := domain.Data{}
+	err := json.Unmarshal(msg, &data)
+	return data, err
+}
+
+type csvDecoder struct{}
+
+func (csvDecoder) Split(data []byte, atEOF bool) (int, []byte, error) {
+	return bufio.ScanLines(data, atEOF)
+}
+
+// Columns: symbol, price, timestamp (unix ms) and optional quantity, bid, ask
+func (csvDecoder) Decode(msg []byte) (domain.Data, error) {
+	record, err := csv.NewReader(strings.NewReader(string(msg))).Read()
+	if err != nil {
+		return domain.Data{}, err
+	}
+	if len(record) != 3 && len(record) != 6 {
+		return domain.Data{}, fmt.Errorf("expected 3 or 6 columns, got %d", len(record))
+	}
+
+	data := domain.Data{Symbol: strings.TrimSpace(record[0])}
+	if data.Price, err = strconv.ParseFloat(strings.TrimSpace(record[1]), 64); err != nil {
+		return domain.Data{}, err
+	}
+	if data.Timestamp, err = strconv.ParseInt(strings.TrimSpace(record[2]), 10, 64); err != nil {
+		return domain.Data{}, err
+	}
+
+	if len(record) == 6 {
+		for i, field := range []*float64{&data.Quantity, &data.Bid, &data.Ask} {
+			val := strings.TrimSpace(record[3+i])
+			if val == "" {
+				continue
+			}
+			if *field, err = strconv.ParseFloat(val, 64); err != nil {
+				return domain.Data{}, err
+			}
+		}
+	}
+	return data, nil
+}
+
+// Frame: payload length (uint16), payload
+//
+// Payload, big endian: symbol length (uint8), symbol, price (float64), timestamp (int64, unix ms)
+// and optional quantity, bid, ask (float64 each)
+type binaryDecoder struct{}
+
+func (binaryDecoder) Split(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) >= 2 {
+		size := 2 + int(binary.BigEndian.Uint16(data))
+		if len(data) >= size {
+			return size, data[2:size], nil
+		}
+	}
+	if atEOF && len(data) != 0 {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	return 0, nil, nil
+}
+
+func (binaryDecoder) Decode(msg []byte) (domain.Data, error) {
+	if len(msg) < 1 {
+		return domain.Data{}, errors.New("empty frame")
+	}
+
+	symbolLen := int(msg[0])
+	rest := msg[1:]
+	if len(rest) < symbolLen+16 {
+		return domain.Data{}, fmt.Errorf("frame is too short: %d bytes", len(msg))
+	}
+
+	data := domain.Data{Symbol: string(rest[:symbolLen])}
+	rest = rest[symbolLen:]
+	data.Price = math.Float64frombits(binary.BigEndian.Uint64(rest))
+	data.Timestamp = int64(binary.BigEndian.Uint64(rest[8:]))
+	rest = rest[16:]
+
+	switch len(rest) {
+	case 0:
+	case 24:
+		data.Quantity = math.Float64frombits(binary.BigEndian.Uint64(rest))
+		data.Bid = math.Float64frombits(binary.BigEndian.Uint64(rest[8:]))
+		data.Ask = math.Float64frombits(binary.BigEndian.Uint64(rest[16:]))
+	default:
+		return domain.Data{}, fmt.Errorf("unexpected %d bytes after timestamp", len(rest))
+	}
+	return data, nil
+}
@@ -0,0 +1,111 @@
+package datafetcher
+
+import (
+	"marketflow/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type seenTick struct {
+	key string
+	at  time.Time
+}
+
+// TickDeduplicator drops ticks which identity was already seen within the window
+//
+// Identities are kept in arrival order, the oldest ones are forgotten when
+// they leave the window or the MaxEntries limit is reached
+type TickDeduplicator struct {
+	cfg        DedupConfig
+	quarantine domain.TickQuarantine
+	seen       map[string]time.Time
+	order      []seenTick
+}
+
+func NewTickDeduplicator(cfg DedupConfig, quarantine domain.TickQuarantine) *TickDeduplicator {
+	return &TickDeduplicator{cfg: cfg, quarantine: quarantine, seen: make(map[string]time.Time)}
+}
+
+// Filter returns the batch without duplicates, dropped ticks are counted per exchange
+func (d *TickDeduplicator) Filter(batch []domain.Data, now time.Time) []domain.Data {
+	d.expire(now)
+
+	unique := make([]domain.Data, 0, len(batch))
+	for _, tick := range batch {
+		key, ok := d.identity(tick)
+		if !ok {
+			unique = append(unique, tick)
+			continue
+		}
+
+		if _, exists := d.seen[key]; exists {
+			if d.quarantine != nil {
+				d.quarantine.Count(tick.ExchangeName, domain.ReasonDuplicate)
+			}
+			continue
+		}
+
+		if len(d.order) >= d.cfg.MaxEntries {
+			delete(d.seen, d.order[0].key)
+			d.order = d.order[1:]
+		}
+		d.seen[key] = now
+		d.order = append(d.order, seenTick{key: key, at: now})
+		unique = append(unique, tick)
+	}
+	return unique
+}
+
+// Forgets identities older than the window
+func (d *TickDeduplicator) expire(now time.Time) {
+	cutoff := now.Add(-d.cfg.Window)
+	i := 0
+	for i < len(d.order) && d.order[i].at.Before(cutoff) {
+		delete(d.seen, d.order[i].key)
+		i++
+	}
+
+	// Reuse the slice memory instead of growing it forever
+	if i != 0 {
+		d.order = append(d.order[:0], d.order[i:]...)
+	}
+}
+
+// Builds tick identity from the configured fields, ticks without timestamp can't be identified by it
+func (d *TickDeduplicator) identity(tick domain.Data) (string, bool) {
+	var key strings.Builder
+	for _, field := range d.cfg.Fields {
+		switch field {
+		case FieldExchange:
+			key.WriteString(tick.ExchangeName)
+		case FieldSymbol:
+			key.WriteString(tick.Symbol)
+		case FieldPrice:
+			key.WriteString(strconv.FormatFloat(tick.Price, 'g', -1, 64))
+		case FieldTimestamp:
+			if tick.Timestamp == 0 {
+				return "", false
+			}
+			key.WriteString(strconv.FormatInt(tick.Timestamp, 10))
+		}
+		key.WriteByte('|')
+	}
+	return key.String(), true
+}
+
+// DedupTicks is a pipeline stage which drops duplicated ticks of every batch
+func DedupTicks(in chan []domain.Data, d *TickDeduplicator) chan []domain.Data {
+	out := make(chan []domain.Data)
+
+	go func() {
+		defer close(out)
+		for batch := range in {
+			if unique := d.Filter(batch, time.Now()); len(unique) != 0 {
+				out <- unique
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,311 @@
+package datafetcher
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"marketflow/internal/domain"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Exchange is a supervised connection to one live exchange
+//
+// The supervisor goroutine (Supervise) moves the exchange between states:
+// connecting -> streaming -> backing off -> connecting ... and stopped,
+// received messages are sent to messageChan until the exchange is stopped
+type Exchange struct {
+	number      string
+	address     string
+	backoff     BackoffConfig
+	staleAfter  time.Duration
+	transport   Transport
+	messageChan chan string
+	decoder     Decoder
+	recorder    domain.TickRecorder
+	mapper      *SymbolMapper
+	symbols     domain.SymbolRegistry // symbols retired from the registry are not reported
+	quarantine  domain.TickQuarantine
+	stop        chan struct{}
+	stopOnce    sync.Once
+
+	mu          sync.Mutex
+	conn        Feed
+	state       domain.ExchangeState
+	connectedAt time.Time
+	lastMessage time.Time
+	lastTicks   map[string]time.Time // last parsed tick time by symbol
+	stale       bool
+	reconnects  int
+	lastErr     error
+	synthetic   bool // ticks are generated by the hybrid mode failover
+}
+
+// GenerateExchange returns pointer to Exchange data with messageChan, connection is not opened yet
+//
+// The exchange is dialed over TCP, other transports are set before Connect
+// The feed is considered stale after staleAfter without messages (0 disables the watchdog)
+func GenerateExchange(number string, address string, backoff BackoffConfig, staleAfter time.Duration) *Exchange {
+	return &Exchange{
+		number:      number,
+		address:     address,
+		backoff:     backoff,
+		staleAfter:  staleAfter,
+		transport:   tcpTransport{address},
+		messageChan: make(chan string),
+		decoder:     jsonDecoder{},
+		stop:        make(chan struct{}),
+		state:       domain.ExchangeConnecting,
+		lastTicks:   make(map[string]time.Time),
+	}
+}
+
+// Connect dials the exchange once
+func (exch *Exchange) Connect() error {
+	exch.setState(domain.ExchangeConnecting)
+
+	conn, err := exch.transport.Dial()
+	if err != nil {
+		exch.mu.Lock()
+		exch.lastErr = err
+		exch.mu.Unlock()
+		return err
+	}
+
+	exch.mu.Lock()
+	defer exch.mu.Unlock()
+
+	// Exchange could be stopped while dialing
+	select {
+	case <-exch.stop:
+		conn.Close()
+		return errors.New("exchange is stopped")
+	default:
+	}
+
+	exch.conn = conn
+	exch.connectedAt = time.Now()
+	exch.stale = false // the new connection is watched from scratch
+	exch.state = domain.ExchangeStreaming
+	return nil
+}
+
+// Supervise reads the exchange messages and reconnects with exponential backoff when the connection is lost
+func (exch *Exchange) Supervise(wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer close(exch.messageChan)
+	defer exch.setState(domain.ExchangeStopped)
+
+	slog.Info("Starting reading data on exchange", "exchange", exch.number)
+
+	// Failed connection attempts in a row
+	attempt := 0
+	if exch.connection() == nil {
+		attempt = 1
+	}
+
+	for {
+		if exch.connection() == nil {
+			if attempt != 0 {
+				if exch.backoff.MaxRetries != 0 && attempt > exch.backoff.MaxRetries {
+					slog.Error("Giving up on exchange", "exchange", exch.number, "attempts", attempt-1)
+					return
+				}
+
+				delay := exch.backoff.Delay(attempt)
+				slog.Warn("Exchange is not connected, backing off", "exchange", exch.number, "attempt", attempt, "retry_in", delay.String())
+				exch.setState(domain.ExchangeBackingOff)
+				if !exch.sleep(delay) {
+					return
+				}
+
+				exch.mu.Lock()
+				exch.reconnects++
+				exch.mu.Unlock()
+			}
+
+			if err := exch.Connect(); err != nil {
+				slog.Warn("Failed to connect exchange", "exchange", exch.number, "error", err.Error())
+				attempt++
+				continue
+			}
+
+			if attempt != 0 {
+				slog.Info("Reconnected to exchange", "exchange", exch.number, "attempts", attempt)
+			}
+			attempt = 0
+		}
+
+		err := exch.read(exch.connection())
+
+		select {
+		case <-exch.stop:
+			return
+		default:
+		}
+
+		exch.mu.Lock()
+		if exch.stale {
+			err = fmt.Errorf("feed is stale, no messages for %s", exch.staleAfter)
+		} else if err == nil {
+			err = errors.New("connection closed by exchange")
+		}
+		slog.Warn("Connection lost on exchange, reconnecting", "exchange", exch.number, "error", err.Error())
+
+		exch.lastErr = err
+		exch.conn.Close()
+		exch.conn = nil
+		exch.mu.Unlock()
+
+		attempt = 1
+	}
+}
+
+// Reads messages framed by the exchange decoder until the connection is broken or the exchange is stopped
+func (exch *Exchange) read(conn Feed) error {
+	return conn.Read(exch.decoder.Split, func(msg []byte) bool {
+		exch.mu.Lock()
+		exch.lastMessage = time.Now()
+		exch.stale = false
+		exch.mu.Unlock()
+
+		select {
+		case <-exch.stop:
+			return false
+		case exch.messageChan <- string(msg):
+			return true
+		}
+	})
+}
+
+// Watchdog forces reconnect when the streaming feed is silent longer than staleAfter (0 disables it),
+// last tick times of symbols retired from the registry are pruned by it too
+func (exch *Exchange) Watchdog() {
+	interval := time.Second
+	if exch.staleAfter != 0 {
+		interval = max(exch.staleAfter/4, 100*time.Millisecond)
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-exch.stop:
+			return
+		case <-t.C:
+			exch.mu.Lock()
+			exch.pruneRetired()
+
+			silence := time.Since(exch.lastMessage)
+			if exch.connectedAt.After(exch.lastMessage) {
+				silence = time.Since(exch.connectedAt)
+			}
+
+			if exch.staleAfter != 0 && exch.state == domain.ExchangeStreaming && exch.conn != nil && !exch.stale && silence > exch.staleAfter {
+				slog.Warn("Exchange feed is stale, forcing reconnect", "exchange", exch.number, "silence", silence.String())
+				exch.stale = true
+				// Supervisor gets read error and reconnects
+				exch.conn.Close()
+			}
+			exch.mu.Unlock()
+		}
+	}
+}
+
+// Removes last tick times of the symbols retired from the registry, exch.mu must be locked
+func (exch *Exchange) pruneRetired() {
+	if exch.symbols == nil {
+		return
+	}
+	for symbol := range exch.lastTicks {
+		if !exch.symbols.Active(symbol) {
+			delete(exch.lastTicks, symbol)
+		}
+	}
+}
+
+// Saves the last tick time of the symbol
+func (exch *Exchange) touchSymbol(symbol string, at time.Time) {
+	exch.mu.Lock()
+	exch.lastTicks[symbol] = at
+	exch.mu.Unlock()
+}
+
+// Waits backoff delay, returns false if the exchange was stopped
+func (exch *Exchange) sleep(delay time.Duration) bool {
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-exch.stop:
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// Stop closes the connection and finishes the supervisor
+func (exch *Exchange) Stop() {
+	exch.stopOnce.Do(func() {
+		close(exch.stop)
+
+		exch.mu.Lock()
+		defer exch.mu.Unlock()
+		if exch.conn != nil {
+			if err := exch.conn.Close(); err != nil {
+				slog.Warn("Failed to close connection", "exchange", exch.number, "error", err.Error())
+			}
+		}
+	})
+}
+
+// Status returns connection report of the exchange
+func (exch *Exchange) Status() domain.ExchangeStatus {
+	exch.mu.Lock()
+	defer exch.mu.Unlock()
+
+	status := domain.ExchangeStatus{
+		Name:        exch.number,
+		Address:     exch.address,
+		State:       exch.state,
+		LastMessage: exch.lastMessage,
+		LastTicks:   make(map[string]time.Time, len(exch.lastTicks)),
+		Stale:       exch.stale,
+		Reconnects:  exch.reconnects,
+		Synthetic:   exch.synthetic,
+	}
+	if exch.lastErr != nil {
+		status.LastError = exch.lastErr.Error()
+	}
+
+	for symbol, at := range exch.lastTicks {
+		if exch.symbols != nil && !exch.symbols.Active(symbol) {
+			continue
+		}
+		status.LastTicks[symbol] = at
+		if exch.staleAfter != 0 && time.Since(at) > exch.staleAfter {
+			status.StaleSymbols = append(status.StaleSymbols, symbol)
+		}
+	}
+	sort.Strings(status.StaleSymbols)
+	return status
+}
+
+func (exch *Exchange) connection() Feed {
+	exch.mu.Lock()
+	defer exch.mu.Unlock()
+	return exch.conn
+}
+
+func (exch *Exchange) setSynthetic(synthetic bool) {
+	exch.mu.Lock()
+	exch.synthetic = synthetic
+	exch.mu.Unlock()
+}
+
+func (exch *Exchange) setState(state domain.ExchangeState) {
+	exch.mu.Lock()
+	exch.state = state
+	exch.mu.Unlock()
+}
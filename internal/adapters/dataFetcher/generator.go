@@ -0,0 +1,259 @@
+package datafetcher
+
+import (
+	"marketflow/internal/domain"
+	"marketflow/internal/packages/pricemodels"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Spread of exchange quotes around the model price
+const quoteNoise = 0.0005
+
+// Half of the bid-ask spread of synthetic quotes, relative to the price
+const halfSpread = 0.0001
+
+// MarketGenerator produces synthetic ticks: one model price path per active symbol of the registry
+// and a quote of every exchange slightly around it
+type MarketGenerator struct {
+	rng       *rand.Rand
+	cfg       TestModelConfig
+	exchanges []string
+	symbols   domain.SymbolRegistry
+	models    map[string]pricemodels.Model
+	prices    map[string]float64 // current model price by symbol
+
+	// Scenario is executed in generated time, so the same seed gives the same run
+	scenario *Scenario
+	elapsed  time.Duration
+	shocked  map[int]time.Duration // permanent crashes by event index and the loop start they were applied in
+
+	mu        sync.Mutex
+	startedAt time.Time
+	lastTicks map[string]map[string]time.Time // last tick time by exchange and symbol
+}
+
+// NewMarketGenerator uses parameters from cfg, symbols missing there get the defaults of modelParams
+func NewMarketGenerator(cfg TestModelConfig, exchanges []string, symbols domain.SymbolRegistry) (*MarketGenerator, error) {
+	if _, err := pricemodels.New(cfg.Model, pricemodels.Params{}); err != nil {
+		return nil, err
+	}
+
+	g := &MarketGenerator{
+		rng:       rand.New(rand.NewSource(cfg.Seed)),
+		cfg:       cfg,
+		exchanges: exchanges,
+		symbols:   symbols,
+		models:    make(map[string]pricemodels.Model),
+		prices:    make(map[string]float64),
+		shocked:   make(map[int]time.Duration),
+		startedAt: time.Now(),
+		lastTicks: make(map[string]map[string]time.Time, len(exchanges)),
+	}
+
+	for _, ex := range exchanges {
+		g.lastTicks[ex] = make(map[string]time.Time)
+	}
+
+	return g, nil
+}
+
+// SetScenario makes the generator execute scenario events, time is counted from the first batch
+func (g *MarketGenerator) SetScenario(scenario *Scenario) {
+	g.scenario = scenario
+}
+
+// Batch moves every symbol price forward by dt and returns quotes of all exchanges
+func (g *MarketGenerator) Batch(now time.Time, dt time.Duration) []domain.Data {
+	symbols := g.symbols.List()
+	rawData := make([]domain.Data, 0, len(g.exchanges)*len(symbols))
+	seconds := dt.Seconds() * g.cfg.TimeScale
+
+	for _, symbol := range symbols {
+		model := g.model(symbol)
+		g.prices[symbol] = model.Next(g.prices[symbol], seconds, g.rng)
+	}
+
+	g.elapsed += dt
+	at, loopStart := g.scenarioTime()
+	g.applyShocks(symbols, at, loopStart)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Last tick times of the retired symbols are pruned
+	for _, ticks := range g.lastTicks {
+		for symbol := range ticks {
+			if !slices.Contains(symbols, symbol) {
+				delete(ticks, symbol)
+			}
+		}
+	}
+
+	for _, ex := range g.exchanges {
+		if g.silent(ex, at) {
+			continue
+		}
+
+		for _, symbol := range symbols {
+			price := g.prices[symbol] * g.quoteFactor(ex, symbol, at)
+
+			// Burst ticks are spread over the batch interval before the regular one
+			ticks := 1 + g.burstTicks(ex, symbol, at, dt)
+			for i := ticks - 1; i >= 0; i-- {
+				quote := price * (1 + g.rng.NormFloat64()*quoteNoise)
+				rawData = append(rawData, domain.Data{
+					ExchangeName: ex,
+					Symbol:       symbol,
+					Price:        quote,
+					Quantity:     g.rng.ExpFloat64(),
+					Bid:          quote * (1 - halfSpread),
+					Ask:          quote * (1 + halfSpread),
+					Timestamp:    now.Add(-dt * time.Duration(i) / time.Duration(ticks)).UnixMilli(),
+					Origin:       domain.OriginSynthetic,
+				})
+			}
+			g.lastTicks[ex][symbol] = now
+		}
+	}
+
+	return rawData
+}
+
+// LastTicks returns the last tick time by exchange and active symbol and the generator start time
+func (g *MarketGenerator) LastTicks() (map[string]map[string]time.Time, time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result := make(map[string]map[string]time.Time, len(g.lastTicks))
+	for ex, ticks := range g.lastTicks {
+		result[ex] = make(map[string]time.Time, len(ticks))
+		for symbol, at := range ticks {
+			// Retired symbols are not generated anymore
+			if !g.symbols.Active(symbol) {
+				continue
+			}
+			result[ex][symbol] = at
+		}
+	}
+	return result, g.startedAt
+}
+
+// Returns position in the scenario and the start of the current loop
+func (g *MarketGenerator) scenarioTime() (time.Duration, time.Duration) {
+	if g.scenario == nil || !g.scenario.Loop {
+		return g.elapsed, 0
+	}
+	length := g.scenario.length()
+	at := g.elapsed % length
+	return at, g.elapsed - at
+}
+
+// Returns model of the symbol, symbols added to the registry start from their base price
+func (g *MarketGenerator) model(symbol string) pricemodels.Model {
+	if model, ok := g.models[symbol]; ok {
+		return model
+	}
+
+	params, ok := g.cfg.Params[symbol]
+	if !ok {
+		params = modelParams(symbol)
+	}
+
+	// Model name is checked by the constructor
+	model, _ := pricemodels.New(g.cfg.Model, params)
+	g.models[symbol] = model
+	g.prices[symbol] = params.Base
+	return model
+}
+
+// Flash crashes without duration move the model price once per loop
+func (g *MarketGenerator) applyShocks(symbols []string, at, loopStart time.Duration) {
+	if g.scenario == nil {
+		return
+	}
+
+	for i, event := range g.scenario.Events {
+		if event.Type != FlashCrash || event.Duration != 0 {
+			continue
+		}
+		if _, active := event.progress(at); !active {
+			continue
+		}
+		if applied, ok := g.shocked[i]; ok && applied == loopStart {
+			continue
+		}
+
+		g.shocked[i] = loopStart
+		for _, symbol := range symbols {
+			if event.matches("", symbol) {
+				g.prices[symbol] *= 1 - event.Percent/100
+			}
+		}
+	}
+}
+
+// Price multiplier of the exchange quote made by active crashes and divergences
+func (g *MarketGenerator) quoteFactor(exchange, symbol string, at time.Duration) float64 {
+	factor := 1.0
+	if g.scenario == nil {
+		return factor
+	}
+
+	for _, event := range g.scenario.Events {
+		if !event.matches(exchange, symbol) {
+			continue
+		}
+		progress, active := event.progress(at)
+		if !active {
+			continue
+		}
+
+		switch event.Type {
+		case FlashCrash:
+			// Crash happens at once and recovers linearly
+			if event.Duration != 0 {
+				factor *= 1 - event.Percent/100*(1-progress)
+			}
+		case Divergence:
+			factor *= 1 + event.Percent/100
+		}
+	}
+	return factor
+}
+
+func (g *MarketGenerator) silent(exchange string, at time.Duration) bool {
+	if g.scenario == nil {
+		return false
+	}
+
+	for _, event := range g.scenario.Events {
+		if event.Type != ExchangeSilence || event.Exchange != exchange {
+			continue
+		}
+		if _, active := event.progress(at); active {
+			return true
+		}
+	}
+	return false
+}
+
+// Extra ticks of active bursts for the batch interval
+func (g *MarketGenerator) burstTicks(exchange, symbol string, at, dt time.Duration) int {
+	if g.scenario == nil {
+		return 0
+	}
+
+	ticks := 0
+	for _, event := range g.scenario.Events {
+		if event.Type != Burst || !event.matches(exchange, symbol) {
+			continue
+		}
+		if _, active := event.progress(at); active {
+			ticks += int(float64(event.Ticks) * dt.Seconds())
+		}
+	}
+	return ticks
+}
@@ -0,0 +1,184 @@
+package datafetcher
+
+import (
+	"hash/fnv"
+	"log/slog"
+	"marketflow/internal/domain"
+	"marketflow/internal/packages/pricemodels"
+	"sync"
+	"time"
+)
+
+// HybridMode is the live mode where every exchange is replaced by a synthetic generator while it is down,
+// the generator continues from the last live prices and the exchange switches back after reconnect
+type HybridMode struct {
+	*LiveMode
+}
+
+var _ domain.DataFetcher = (*HybridMode)(nil)
+
+func NewHybridModeFetcher(recorder domain.TickRecorder, quarantine domain.TickQuarantine, symbols domain.SymbolRegistry, push *PushIngest) *HybridMode {
+	return &HybridMode{LiveMode: NewLiveModeFetcher(recorder, quarantine, symbols, push)}
+}
+
+// SetupDataFetcher starts even if no exchange is reachable, all of them wait for the first live prices then
+func (m *HybridMode) SetupDataFetcher() (chan map[string]domain.ExchangeData, chan []domain.Data, error) {
+	cfg := LoadTestModelConfig(m.symbols.List())
+	// Synthetic ticks should look like continuation of the live feed,
+	// uniform noise has no memory of the price, so the path model is used instead
+	cfg.TimeScale = 1
+	if cfg.Model == pricemodels.Uniform {
+		cfg.Model = pricemodels.GBM
+	}
+
+	market := &livePrices{prices: make(map[string]float64)}
+	return m.setup(func(exch *Exchange, flow chan domain.Data) chan domain.Data {
+		return failover(exch, flow, cfg, m.symbols, market)
+	})
+}
+
+// Last live price of every symbol on any exchange
+type livePrices struct {
+	mu     sync.Mutex
+	prices map[string]float64
+}
+
+func (p *livePrices) set(symbol string, price float64) {
+	p.mu.Lock()
+	p.prices[symbol] = price
+	p.mu.Unlock()
+}
+
+func (p *livePrices) get(symbol string) (float64, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	price, ok := p.prices[symbol]
+	return price, ok
+}
+
+// Passes live ticks of the exchange and adds synthetic ticks every second while the exchange is not streaming,
+// an exchange which was given up keeps synthetic data until the mode is closed
+//
+// Synthetic prices start from the last prices of the exchange, symbols which it has never sent start from
+// the last live price of the other exchanges, symbols without any live price are not generated
+func failover(exch *Exchange, live chan domain.Data, cfg TestModelConfig, symbols domain.SymbolRegistry, market *livePrices) chan domain.Data {
+	out := make(chan domain.Data)
+
+	go func() {
+		defer close(out)
+		t := time.NewTicker(time.Second)
+		defer t.Stop()
+
+		lastPrices := make(map[string]float64)
+		var (
+			generator *MarketGenerator
+			seeded    map[string]float64 // start prices of the generated symbols
+		)
+
+		for {
+			select {
+			case <-exch.stop:
+				// Workers are finishing, their last ticks are passed before the flow is closed
+				if live != nil {
+					for data := range live {
+						out <- data
+					}
+				}
+				return
+			case data, ok := <-live:
+				if !ok {
+					// Workers are finished after the supervisor gave up, nil channel is never selected
+					live = nil
+					continue
+				}
+				lastPrices[data.Symbol] = data.Price
+				market.set(data.Symbol, data.Price)
+				out <- data
+			case now := <-t.C:
+				status := exch.Status()
+				down := status.State != domain.ExchangeStreaming || status.Stale
+
+				if !down {
+					if generator != nil {
+						generator, seeded = nil, nil
+						exch.setSynthetic(false)
+						slog.Info("Exchange is streaming again, switched back to live data", "exchange", exch.number)
+					}
+					continue
+				}
+
+				// Symbols which got their first live price on other exchanges are added to the generator
+				seeds := seedPrices(symbols, lastPrices, market)
+				if unseeded(seeds, seeded) {
+					g, err := failoverGenerator(cfg, exch.number, seeds, symbols)
+					if err != nil {
+						slog.Error("Failed to start synthetic data for exchange", "exchange", exch.number, "error", err.Error())
+						continue
+					}
+					if generator == nil {
+						exch.setSynthetic(true)
+						slog.Warn("Exchange is down, switched to synthetic data", "exchange", exch.number, "state", string(status.State))
+					}
+					generator, seeded = g, seeds
+				}
+				if generator == nil {
+					continue
+				}
+
+				for _, data := range generator.Batch(now, time.Second) {
+					if _, ok := seeded[data.Symbol]; !ok {
+						continue // symbol has no live price yet
+					}
+					lastPrices[data.Symbol] = data.Price
+					out <- data
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Reports if some of the seeds are not used by the generator yet
+func unseeded(seeds, seeded map[string]float64) bool {
+	for symbol := range seeds {
+		if _, ok := seeded[symbol]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns start prices of the active symbols: the last price of the exchange or the last live price of the market
+func seedPrices(symbols domain.SymbolRegistry, lastPrices map[string]float64, market *livePrices) map[string]float64 {
+	seeds := make(map[string]float64)
+	for _, symbol := range symbols.List() {
+		if price, ok := lastPrices[symbol]; ok {
+			seeds[symbol] = price
+		} else if price, ok := market.get(symbol); ok {
+			seeds[symbol] = price
+		}
+	}
+	return seeds
+}
+
+// Returns generator of one exchange which starts from the seed prices
+func failoverGenerator(cfg TestModelConfig, exchange string, seeds map[string]float64, symbols domain.SymbolRegistry) (*MarketGenerator, error) {
+	params := make(map[string]pricemodels.Params, len(seeds))
+	for symbol, price := range seeds {
+		p, ok := cfg.Params[symbol]
+		if !ok {
+			p = modelParams(symbol)
+		}
+		p.Base = price
+		params[symbol] = p
+	}
+	cfg.Params = params
+
+	// Exchanges which are down at the same time get different series
+	h := fnv.New64a()
+	h.Write([]byte(exchange))
+	cfg.Seed ^= int64(h.Sum64())
+
+	return NewMarketGenerator(cfg, []string{exchange}, symbols)
+}
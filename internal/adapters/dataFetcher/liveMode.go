@@ -1,49 +1,63 @@
 package datafetcher
 
 import (
-	"bufio"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"log/slog"
 	"marketflow/internal/domain"
-	"math"
-	"net"
-	"os"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-type Exchange struct {
-	number      string
-	conn        net.Conn
-	closeCh     chan bool
-	messageChan chan string
-}
-
 type LiveMode struct {
-	Exchanges []*Exchange
-	mu        sync.Mutex
+	Exchanges  []*Exchange
+	configs    []domain.ExchangeConfig
+	backoff    BackoffConfig
+	staleAfter time.Duration
+	recorder   domain.TickRecorder
+	quarantine domain.TickQuarantine
+	symbols    domain.SymbolRegistry
+	push       *PushIngest
+	mu         sync.Mutex
 }
 
-func NewLiveModeFetcher() *LiveMode {
-	return &LiveMode{Exchanges: make([]*Exchange, 0)}
+// NewLiveModeFetcher creates live datafetcher, ticks are teed to the recorder and
+// rejected ticks are kept in the quarantine (both could be nil), only active symbols of the registry are accepted,
+// ticks of the push ingest (could be nil) are merged into the pipeline
+func NewLiveModeFetcher(recorder domain.TickRecorder, quarantine domain.TickQuarantine, symbols domain.SymbolRegistry, push *PushIngest) *LiveMode {
+	return &LiveMode{
+		Exchanges:  make([]*Exchange, 0),
+		configs:    LoadExchangeConfigs(),
+		backoff:    LoadBackoffConfig(),
+		staleAfter: LoadStaleAfter(),
+		recorder:   recorder,
+		quarantine: quarantine,
+		symbols:    symbols,
+		push:       push,
+	}
 }
 
 var _ domain.DataFetcher = (*LiveMode)(nil)
 
 func (m *LiveMode) CheckHealth() error {
+	return checkStatuses(m.ExchangeStatuses())
+}
+
+// Returns error listing exchanges which are not streaming or have stale feeds
+func checkStatuses(statuses []domain.ExchangeStatus) error {
 	var unhealthy string
-	for i := 0; i < len(m.Exchanges); i++ {
-		select {
-		case _, ok := <-m.Exchanges[i].messageChan:
-			if !ok {
-				unhealthy += m.Exchanges[i].number + " "
-			}
-		default:
-			continue
+	for _, status := range statuses {
+		switch {
+		case status.State != domain.ExchangeStreaming && status.Synthetic:
+			unhealthy += status.Name + " (" + string(status.State) + ", synthetic data) "
+		case status.State != domain.ExchangeStreaming:
+			unhealthy += status.Name + " (" + string(status.State) + ") "
+		case status.Stale:
+			unhealthy += status.Name + " (stale) "
+		case len(status.StaleSymbols) != 0:
+			unhealthy += status.Name + " (stale symbols: " + strings.Join(status.StaleSymbols, ", ") + ") "
 		}
 	}
 	if len(unhealthy) != 0 {
@@ -52,187 +66,188 @@ func (m *LiveMode) CheckHealth() error {
 	return nil
 }
 
-func (m *LiveMode) Close() {
+// Returns connection reports of all live exchanges
+func (m *LiveMode) ExchangeStatuses() []domain.ExchangeStatus {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	for i := 0; i < len(m.Exchanges); i++ {
-		if m.Exchanges[i] == nil || m.Exchanges[i].conn == nil {
-			continue
-		}
 
-		if err := m.Exchanges[i].conn.Close(); err != nil {
-			log.Println("Failed to close connection: ", err.Error())
-			continue
-		}
+	statuses := make([]domain.ExchangeStatus, 0, len(m.Exchanges))
+	for _, exch := range m.Exchanges {
+		statuses = append(statuses, exch.Status())
+	}
+	return statuses
+}
 
-		m.Exchanges[i].closeCh <- true
+func (m *LiveMode) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, exch := range m.Exchanges {
+		exch.Stop()
 	}
 }
 
 func (m *LiveMode) SetupDataFetcher() (chan map[string]domain.ExchangeData, chan []domain.Data, error) {
-	dataFlows := [3]chan domain.Data{make(chan domain.Data), make(chan domain.Data), make(chan domain.Data)}
+	return m.setup(nil)
+}
 
-	wg := &sync.WaitGroup{}
+// Starts exchanges, ticks of every exchange flow are passed through failover if it is set
+func (m *LiveMode) setup(failover func(exch *Exchange, flow chan domain.Data) chan domain.Data) (chan map[string]domain.ExchangeData, chan []domain.Data, error) {
+	if len(m.configs) == 0 {
+		return nil, nil, errors.New("no exchanges are configured")
+	}
 
-	ports := []string{os.Getenv("EXCHANGE1_PORT"), os.Getenv("EXCHANGE2_PORT"), os.Getenv("EXCHANGE3_PORT")}
-	exchHosts := []string{os.Getenv("EXCHANGE1_NAME"), os.Getenv("EXCHANGE2_NAME"), os.Getenv("EXCHANGE3_NAME")}
+	dataFlows := make([]chan domain.Data, 0, len(m.configs))
+	exchanges := make([]*Exchange, 0, len(m.configs))
+	connected := 0
 
-	for i := 0; i < len(ports); i++ {
-		wg.Add(1)
-		exch, err := GenerateExchange("Exchange"+strconv.Itoa(i+1), exchHosts[i]+":"+ports[i])
+	// Decoders are resolved before dialing, so a misconfigured exchange doesn't leave the others connected
+	decoders := make([]Decoder, 0, len(m.configs))
+	for _, cfg := range m.configs {
+		decoder, err := NewDecoder(cfg.Decoder)
 		if err != nil {
-			log.Printf("Failed to connect exchange number: %d, error: %s", i+1, err.Error())
-			wg.Done()
-			continue
+			return nil, nil, fmt.Errorf("exchange %s: %w", cfg.Name, err)
 		}
+		decoders = append(decoders, decoder)
+	}
 
-		// Receive data from the server
-		go exch.FetchData(wg)
+	for i, cfg := range m.configs {
+		exch := GenerateExchange(cfg.Name, cfg.Address(), m.backoff, m.staleAfter)
+		exch.decoder = decoders[i]
+		exch.recorder = m.recorder
+		exch.mapper = NewSymbolMapper(cfg.Name, cfg.SymbolAliases, cfg.SymbolNatives, m.symbols)
+		exch.symbols = m.symbols
+		if cfg.URL != "" {
+			exch.transport = wsTransport{url: cfg.URL, subscribe: cfg.Subscribe, pingInterval: cfg.PingInterval, symbols: m.symbols, mapper: exch.mapper}
+		}
+		exch.quarantine = m.quarantine
 
-		// Start the vorker to process the received data
-		go exch.SetWorkers(wg, dataFlows[i])
+		// Unreachable exchanges are retried by their supervisors
+		if err := exch.Connect(); err != nil {
+			log.Printf("Failed to connect exchange: %s, error: %s", cfg.Name, err.Error())
+		} else {
+			connected++
+		}
+		exchanges = append(exchanges, exch)
+	}
 
-		m.Exchanges = append(m.Exchanges, exch)
+	if connected == 0 && failover == nil {
+		return nil, nil, errors.New("failed to connect to any exchange")
 	}
 
-	if len(m.Exchanges) != 3 {
-		return nil, nil, errors.New("failed to connect to 3 exchanges")
+	if connected != len(m.configs) {
+		slog.Warn("Started with part of the exchanges", "connected", connected, "configured", len(m.configs))
 	}
 
+	wg := &sync.WaitGroup{}
+
+	m.mu.Lock()
+	for _, exch := range exchanges {
+		wg.Add(1)
+		flow := make(chan domain.Data)
+
+		// Receive data from the server
+		go exch.Supervise(wg)
+
+		// Reconnect silent feeds
+		go exch.Watchdog()
+
+		// Start the vorker to process the received data
+		go exch.SetWorkers(wg, flow)
+
+		if failover != nil {
+			flow = failover(exch, flow)
+		}
+		dataFlows = append(dataFlows, flow)
+	}
+	m.Exchanges = exchanges
+	m.mu.Unlock()
+
 	mergedCh := MergeFlows(dataFlows)
 
-	aggregated, rawDatach := Aggregate(mergedCh)
+	aggregated, rawDatach := IngestPipeline(mergedCh, m.quarantine, m.symbols, m.push)
 
 	go func() {
 		wg.Wait()
-		for i := 0; i < len(m.Exchanges); i++ {
-			if m.Exchanges[i] == nil {
-				continue
-			}
-			if m.Exchanges[i].conn != nil {
-				m.Exchanges[i].conn.Close()
-			}
-		}
-
 		slog.Info("All workers have finished processing.")
 	}()
 	return aggregated, rawDatach, nil
 }
 
-func Aggregate(mergedCh chan []domain.Data) (chan map[string]domain.ExchangeData, chan []domain.Data) {
+// Aggregate buckets ticks into event-time windows and sends every closed window aggregates,
+// raw batches are passed to the second channel as they are
+func Aggregate(mergedCh chan []domain.Data, quarantine domain.TickQuarantine) (chan map[string]domain.ExchangeData, chan []domain.Data) {
 	aggregatedCh := make(chan map[string]domain.ExchangeData)
 	rawDataCh := make(chan []domain.Data)
 
-	go func() {
-		for dataBatch := range mergedCh {
+	// Raw batches which are still waiting to be sent
+	rawWg := &sync.WaitGroup{}
 
-			// To prevent the main thread from being delayed
-			go func() {
-				rawDataCh <- dataBatch
-			}()
+	windows := NewEventTimeWindows(LoadWindowConfig(), quarantine)
 
-			exchangesData := make(map[string]domain.ExchangeData)
-			counts := make(map[string]int)
-			sums := make(map[string]float64)
+	go func() {
+		// Closes windows of silent feeds
+		t := time.NewTicker(time.Second)
+		defer t.Stop()
 
-			for _, data := range dataBatch {
-				keys := []string{
-					data.ExchangeName + " " + data.Symbol, // by exchange
-					"All " + data.Symbol,                  // by all exchanges
+	mainLoop:
+		for {
+			select {
+			case dataBatch, ok := <-mergedCh:
+				if !ok {
+					break mainLoop
 				}
 
-				for _, key := range keys {
-					val, exists := exchangesData[key]
-					if !exists {
-						val = domain.ExchangeData{
-							Exchange:  strings.Split(key, " ")[0],
-							Pair_name: data.Symbol,
-							Min_price: math.Inf(1),
-							Max_price: math.Inf(-1),
-						}
-					}
-
-					// обновление мин/макс
-					if data.Price < val.Min_price {
-						val.Min_price = data.Price
-					}
-					if data.Price > val.Max_price {
-						val.Max_price = data.Price
-					}
-
-					sums[key] += data.Price
-					counts[key]++
-
-					exchangesData[key] = val
-				}
-			}
+				// To prevent the main thread from being delayed
+				rawWg.Add(1)
+				go func() {
+					defer rawWg.Done()
+					rawDataCh <- dataBatch
+				}()
 
-			// Counting avg price
-			for key, ed := range exchangesData {
-				if count, ok := counts[key]; ok && count > 0 {
-					ed.Average_price = sums[key] / float64(count)
-					ed.Timestamp = time.Now()
-					exchangesData[key] = ed
+				now := time.Now()
+				for _, exchangesData := range windows.Add(dataBatch, now) {
+					aggregatedCh <- exchangesData
+				}
+				for _, exchangesData := range windows.Advance(now) {
+					aggregatedCh <- exchangesData
+				}
+			case now := <-t.C:
+				for _, exchangesData := range windows.Advance(now) {
+					aggregatedCh <- exchangesData
 				}
 			}
+		}
+
+		for _, exchangesData := range windows.Flush() {
 			aggregatedCh <- exchangesData
 		}
 		close(aggregatedCh)
+		rawWg.Wait()
 		close(rawDataCh)
 	}()
 
 	return aggregatedCh, rawDataCh
 }
 
-func MergeFlows(dataFlows [3]chan domain.Data) chan []domain.Data {
-	mergedCh := make(chan domain.Data, 15)
-	ch := make(chan []domain.Data, 3)
-
-	closedCount := 0
-	var muClosed sync.Mutex
-
-	go func() {
-		defer close(mergedCh)
-
-	mainLoop:
-		for {
-			select {
-			case e1, ok := <-dataFlows[0]:
-				if !ok {
-					muClosed.Lock()
-					closedCount++
-					muClosed.Unlock()
-					dataFlows[0] = nil
-				} else {
-					mergedCh <- e1
-				}
-			case e2, ok := <-dataFlows[1]:
-				if !ok {
-					muClosed.Lock()
-					closedCount++
-					muClosed.Unlock()
-					dataFlows[1] = nil
-				} else {
-					mergedCh <- e2
-				}
-			case e3, ok := <-dataFlows[2]:
-				if !ok {
-					muClosed.Lock()
-					closedCount++
-					muClosed.Unlock()
-					dataFlows[2] = nil
-				} else {
-					mergedCh <- e3
-				}
+func MergeFlows(dataFlows []chan domain.Data) chan []domain.Data {
+	mergedCh := make(chan domain.Data, 5*len(dataFlows))
+	ch := make(chan []domain.Data, len(dataFlows))
+
+	// Fan-in: one goroutine per exchange flow
+	flowsWg := &sync.WaitGroup{}
+	for _, flow := range dataFlows {
+		flowsWg.Add(1)
+		go func(flow chan domain.Data) {
+			defer flowsWg.Done()
+			for data := range flow {
+				mergedCh <- data
 			}
+		}(flow)
+	}
 
-			muClosed.Lock()
-			if closedCount == 3 {
-				muClosed.Unlock()
-				break mainLoop
-			}
-			muClosed.Unlock()
-		}
+	go func() {
+		flowsWg.Wait()
+		close(mergedCh)
 	}()
 
 	t := time.NewTicker(time.Second)
@@ -279,79 +294,6 @@ func MergeFlows(dataFlows [3]chan domain.Data) chan []domain.Data {
 	return ch
 }
 
-// GenerateExchange returns pointer to Exchange data with messageChan
-func GenerateExchange(number string, address string) (*Exchange, error) {
-	messageChan := make(chan string)
-
-	conn, err := net.Dial("tcp", address)
-	if err != nil {
-		return nil, err
-	}
-
-	exchangeServ := &Exchange{number: number, conn: conn, messageChan: messageChan}
-	return exchangeServ, nil
-}
-
-func (exch *Exchange) Reconnect(address string) error {
-	var err error
-	for i := 0; i < 5; i++ {
-		time.Sleep(2 * time.Second)
-		exch.conn, err = net.Dial("tcp", address)
-		if err == nil {
-			slog.Info("Reconnected to exchange: " + exch.number)
-			return nil
-		}
-		slog.Warn("Reconnect attempt failed: " + err.Error())
-	}
-	return err
-}
-
-func (exch *Exchange) FetchData(wg *sync.WaitGroup) {
-	defer wg.Done()
-
-	scanner := bufio.NewScanner(exch.conn)
-	address := exch.conn.RemoteAddr().String()
-
-	closeCh := make(chan bool, 1)
-	exch.closeCh = closeCh
-
-	reconnect := true
-	mu := sync.Mutex{}
-
-	go func() {
-		<-closeCh
-		mu.Lock()
-		reconnect = false
-		mu.Unlock()
-	}()
-
-	log.Println("Starting reading data on exchange: ", exch.number)
-
-	for {
-		for scanner.Scan() && reconnect {
-			line := scanner.Text()
-			exch.messageChan <- line
-		}
-
-		log.Printf("Connection lost on exchange %s. Reconnecting...\n", exch.number)
-
-		if reconnect {
-			if err := exch.Reconnect(address); err != nil {
-				log.Printf("Failed to reconnect exchange %s: %v", exch.number, err)
-				break
-			}
-
-			scanner = bufio.NewScanner(exch.conn)
-		} else {
-			break
-		}
-	}
-
-	log.Println("Giving up on exchange: ", exch.number)
-	close(closeCh)
-	close(exch.messageChan)
-}
-
 // SetWorkers starts goroutine workers to process data
 func (exch *Exchange) SetWorkers(globalWg *sync.WaitGroup, fan_in chan domain.Data) {
 	workerWg := &sync.WaitGroup{}
@@ -359,7 +301,7 @@ func (exch *Exchange) SetWorkers(globalWg *sync.WaitGroup, fan_in chan domain.Da
 		workerWg.Add(1)
 		globalWg.Add(1)
 		go func() {
-			Worker(exch.number, exch.messageChan, fan_in, workerWg)
+			exch.Worker(fan_in, workerWg)
 			globalWg.Done()
 		}()
 	}
@@ -371,19 +313,36 @@ func (exch *Exchange) SetWorkers(globalWg *sync.WaitGroup, fan_in chan domain.Da
 	}()
 }
 
-// Worker processes tasks from the jobs channel and sends the results to the results channel
-func Worker(number string, jobs chan string, results chan domain.Data, wg *sync.WaitGroup) {
+// Worker processes lines from the exchange messageChan and sends the results to the results channel
+func (exch *Exchange) Worker(results chan domain.Data, wg *sync.WaitGroup) {
 	defer wg.Done()
-	for j := range jobs {
-		data := domain.Data{}
-		err := json.Unmarshal([]byte(j), &data)
+	for j := range exch.messageChan {
+		receivedAt := time.Now()
+		data, err := exch.decoder.Decode([]byte(j))
 		if err != nil {
-			log.Printf("Unmarshalling error in worker %s", err.Error())
+			log.Printf("Decoding error in worker %s", err.Error())
 			continue
 		}
 
+		// Native symbols are replaced with the canonical ones before aggregation
+		if exch.mapper != nil {
+			symbol, ok := exch.mapper.Map(data.Symbol)
+			if !ok {
+				if exch.quarantine != nil {
+					exch.quarantine.Count(exch.number, domain.ReasonUnmapped)
+				}
+				continue
+			}
+			data.Symbol = symbol
+		}
+
 		// Assign the name of the exchange and send it to the results channel
-		data.ExchangeName = number
+		data.ExchangeName = exch.number
+		data.Origin = domain.OriginLive
+		exch.touchSymbol(data.Symbol, receivedAt)
+		if exch.recorder != nil {
+			exch.recorder.Record(data, j, receivedAt)
+		}
 		results <- data
 	}
 }
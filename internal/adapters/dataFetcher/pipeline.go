@@ -0,0 +1,16 @@
+package datafetcher
+
+import "marketflow/internal/domain"
+
+// IngestPipeline chains the processing stages shared by all datafetcher modes:
+// pushed ticks merge (push could be nil) -> de-duplication -> ticks validation -> event-time aggregation
+func IngestPipeline(in chan []domain.Data, quarantine domain.TickQuarantine, symbols domain.SymbolRegistry, push *PushIngest) (chan map[string]domain.ExchangeData, chan []domain.Data) {
+	if push != nil {
+		in = push.merge(in)
+	}
+	if dedupCfg := LoadDedupConfig(); dedupCfg.Window != 0 {
+		in = DedupTicks(in, NewTickDeduplicator(dedupCfg, quarantine))
+	}
+	validated := ValidateTicks(in, NewTickValidator(LoadValidatorConfig(), quarantine, symbols))
+	return Aggregate(validated, quarantine)
+}
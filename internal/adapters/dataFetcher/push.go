@@ -0,0 +1,65 @@
+package datafetcher
+
+import (
+	"marketflow/internal/domain"
+	"marketflow/internal/packages/ratelimit"
+	"time"
+)
+
+// PushIngest passes ticks pushed by external producers into the ingest pipeline of the current mode
+//
+// It outlives the datafetcher modes, every IngestPipeline merges its batches with the mode ticks.
+// Every source has its own token bucket of INGEST_RATE ticks per second up to INGEST_BURST
+type PushIngest struct {
+	batches chan []domain.Data
+	limiter *ratelimit.Limiter
+}
+
+func NewPushIngest(cfg IngestConfig) *PushIngest {
+	return &PushIngest{
+		batches: make(chan []domain.Data, 100),
+		limiter: ratelimit.New(cfg.Rate, cfg.Burst),
+	}
+}
+
+// Push sends the batch of the source to the pipeline, it waits a second if the pipeline is busy
+func (p *PushIngest) Push(source string, batch []domain.Data) error {
+	if len(batch) > p.limiter.Burst() {
+		return domain.ErrIngestBatchTooLarge
+	}
+	if !p.limiter.AllowN(source, len(batch), time.Now()) {
+		return domain.ErrIngestRateLimited
+	}
+
+	t := time.NewTimer(time.Second)
+	defer t.Stop()
+
+	select {
+	case p.batches <- batch:
+		return nil
+	case <-t.C:
+		return domain.ErrIngestBusy
+	}
+}
+
+// Returns flow of the mode ticks and the pushed ones, it is closed when the mode flow is closed
+func (p *PushIngest) merge(in chan []domain.Data) chan []domain.Data {
+	out := make(chan []domain.Data)
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case batch, ok := <-in:
+				if !ok {
+					return
+				}
+				out <- batch
+			case batch := <-p.batches:
+				out <- batch
+			}
+		}
+	}()
+
+	return out
+}
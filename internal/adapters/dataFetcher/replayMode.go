@@ -0,0 +1,282 @@
+package datafetcher
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"marketflow/internal/domain"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayMode plays back recorded ticks from NDJSON or CSV files
+//
+// Ticks are grouped by the second of their recorded timestamp (unix ms),
+// every group is sent to Aggregate as one batch.
+// Speed 1 keeps the recorded pace, speed N plays N times faster, speed 0 plays as fast as possible
+type ReplayMode struct {
+	path       string
+	speed      float64
+	stop       chan struct{}
+	stopOnce   sync.Once
+	quarantine domain.TickQuarantine
+	symbols    domain.SymbolRegistry
+	push       *PushIngest
+
+	mu       sync.Mutex
+	err      error
+	finished bool
+	replayed int
+}
+
+var _ domain.DataFetcher = (*ReplayMode)(nil)
+
+func NewReplayModeFetcher(path string, speed float64, quarantine domain.TickQuarantine, symbols domain.SymbolRegistry, push *PushIngest) *ReplayMode {
+	return &ReplayMode{path: path, speed: speed, stop: make(chan struct{}), quarantine: quarantine, symbols: symbols, push: push}
+}
+
+// ResolveReplayFile returns path of the recorded file inside REPLAY_DIR (current directory by default)
+func ResolveReplayFile(name string) (string, error) {
+	return resolveFile("REPLAY_DIR", name)
+}
+
+// ParseReplaySpeed converts speed parameter: "" means real speed, "max" or "0" means as fast as possible
+func ParseReplaySpeed(speed string) (float64, error) {
+	switch speed {
+	case "":
+		return 1, nil
+	case "max":
+		return 0, nil
+	}
+
+	val, err := strconv.ParseFloat(speed, 64)
+	if err != nil || val < 0 {
+		return 0, fmt.Errorf("replay speed is invalid: %s", speed)
+	}
+	return val, nil
+}
+
+func (m *ReplayMode) SetupDataFetcher() (chan map[string]domain.ExchangeData, chan []domain.Data, error) {
+	file, err := os.Open(m.path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ticks := make(chan domain.Data, 100)
+	rawFlow := make(chan []domain.Data, 100)
+
+	// Reading goroutine
+	go func() {
+		defer file.Close()
+		defer close(ticks)
+
+		if err := readRecordedTicks(file, strings.EqualFold(filepath.Ext(m.path), ".csv"), ticks, m.stop); err != nil {
+			slog.Error("Failed to read replay file", "file", m.path, "error", err.Error())
+			m.mu.Lock()
+			m.err = err
+			m.mu.Unlock()
+		}
+	}()
+
+	// Pacing goroutine
+	go func() {
+		defer close(rawFlow)
+
+		var (
+			batch    []domain.Data
+			batchSec int64
+		)
+
+		for tick := range ticks {
+			sec := tick.Timestamp / 1000
+			if len(batch) != 0 && sec != batchSec {
+				if !m.send(rawFlow, batch) || !m.wait(time.Duration(sec-batchSec)*time.Second) {
+					return
+				}
+				batch = nil
+			}
+			batchSec = sec
+			batch = append(batch, tick)
+		}
+
+		if len(batch) != 0 && !m.send(rawFlow, batch) {
+			return
+		}
+
+		m.mu.Lock()
+		m.finished = true
+		m.mu.Unlock()
+		slog.Info("Replay finished", "file", m.path, "ticks", m.replayed)
+	}()
+
+	aggregatedCh, rawCh := IngestPipeline(rawFlow, m.quarantine, m.symbols, m.push)
+	return aggregatedCh, rawCh, nil
+}
+
+// Sends batch to the aggregation, returns false if replay was stopped
+func (m *ReplayMode) send(rawFlow chan []domain.Data, batch []domain.Data) bool {
+	select {
+	case <-m.stop:
+		return false
+	case rawFlow <- batch:
+		m.mu.Lock()
+		m.replayed += len(batch)
+		m.mu.Unlock()
+		return true
+	}
+}
+
+// Waits recorded gap divided by replay speed, returns false if replay was stopped
+func (m *ReplayMode) wait(gap time.Duration) bool {
+	if m.speed == 0 || gap <= 0 {
+		select {
+		case <-m.stop:
+			return false
+		default:
+			return true
+		}
+	}
+
+	t := time.NewTimer(time.Duration(float64(gap) / m.speed))
+	defer t.Stop()
+
+	select {
+	case <-m.stop:
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// Reads ticks line by line and sends them to the ticks channel
+func readRecordedTicks(r io.Reader, isCSV bool, ticks chan domain.Data, stop chan struct{}) error {
+	next := ndjsonReader(r)
+	if isCSV {
+		next = csvReader(r)
+	}
+
+	for line := 1; ; line++ {
+		tick, err := next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if tick.ExchangeName == "" || tick.Symbol == "" || tick.Timestamp == 0 {
+			slog.Warn("Skipping recorded tick without exchange, symbol or timestamp", "line", line)
+			continue
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case ticks <- tick:
+		}
+	}
+}
+
+func ndjsonReader(r io.Reader) func() (domain.Data, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	return func() (domain.Data, error) {
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+
+			tick := domain.Data{}
+			if err := json.Unmarshal([]byte(line), &tick); err != nil {
+				return tick, err
+			}
+			return tick, nil
+		}
+
+		if err := scanner.Err(); err != nil {
+			return domain.Data{}, err
+		}
+		return domain.Data{}, io.EOF
+	}
+}
+
+// CSV columns: exchange, symbol, price, timestamp (unix ms) and optional quantity, bid, ask,
+// the header row is optional
+func csvReader(r io.Reader) func() (domain.Data, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	first := true
+
+	return func() (domain.Data, error) {
+		for {
+			record, err := reader.Read()
+			if err != nil {
+				return domain.Data{}, err
+			}
+			if len(record) != 4 && len(record) != 7 {
+				return domain.Data{}, fmt.Errorf("expected 4 or 7 columns, got %d", len(record))
+			}
+
+			price, priceErr := strconv.ParseFloat(record[2], 64)
+			if first {
+				first = false
+				if priceErr != nil {
+					continue // header row
+				}
+			}
+			if priceErr != nil {
+				return domain.Data{}, priceErr
+			}
+
+			timestamp, err := strconv.ParseInt(record[3], 10, 64)
+			if err != nil {
+				return domain.Data{}, err
+			}
+
+			tick := domain.Data{ExchangeName: record[0], Symbol: record[1], Price: price, Timestamp: timestamp}
+			if len(record) == 7 {
+				for i, field := range []*float64{&tick.Quantity, &tick.Bid, &tick.Ask} {
+					if record[4+i] == "" {
+						continue
+					}
+					if *field, err = strconv.ParseFloat(record[4+i], 64); err != nil {
+						return domain.Data{}, err
+					}
+				}
+			}
+			return tick, nil
+		}
+	}
+}
+
+func (m *ReplayMode) CheckHealth() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return errors.New("replay failed: " + m.err.Error())
+	}
+	if m.finished {
+		return fmt.Errorf("replay of %s finished, %d ticks replayed", filepath.Base(m.path), m.replayed)
+	}
+	return nil
+}
+
+// Replay mode has no exchange connections
+func (m *ReplayMode) ExchangeStatuses() []domain.ExchangeStatus {
+	return nil
+}
+
+func (m *ReplayMode) Close() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
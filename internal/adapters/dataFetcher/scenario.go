@@ -0,0 +1,176 @@
+package datafetcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"marketflow/internal/domain"
+	"os"
+	"time"
+)
+
+// Scenario event types
+const (
+	FlashCrash      = "flash_crash"      // symbol price falls by percent and recovers during duration (keeps falling without duration)
+	ExchangeSilence = "exchange_silence" // exchange sends nothing during duration
+	Divergence      = "divergence"       // exchange quotes are shifted by percent during duration
+	Burst           = "burst"            // every exchange sends extra ticks per second during duration
+)
+
+// Scenario is a list of timed events executed by the test mode generator
+//
+// Crashes and divergences are sudden moves, so they pass the ingest validation only while they stay
+// within OUTLIER_MAX_DEVIATION_PCT of the rolling median, bigger ones are quarantined as outliers
+// until the median catches up.
+//
+// Example:
+//
+//	{"loop": false, "events": [
+//	  {"at": "10s", "type": "flash_crash", "symbol": "BTCUSDT", "percent": 12, "duration": "30s"},
+//	  {"at": "1m", "type": "exchange_silence", "exchange": "Exchange2", "duration": "15s"},
+//	  {"at": "90s", "type": "divergence", "exchange": "Exchange3", "symbol": "ETHUSDT", "percent": 5, "duration": "30s"},
+//	  {"at": "2m", "type": "burst", "symbol": "SOLUSDT", "ticks": 50, "duration": "5s"}
+//	]}
+type Scenario struct {
+	Events []ScenarioEvent `json:"events"`
+	Loop   bool            `json:"loop"` // start again after the last event ends
+}
+
+// ScenarioEvent times are counted from the start of the test mode,
+// empty symbol or exchange means all of them
+type ScenarioEvent struct {
+	At       Duration `json:"at"`
+	Type     string   `json:"type"`
+	Symbol   string   `json:"symbol,omitempty"`
+	Exchange string   `json:"exchange,omitempty"`
+	Percent  float64  `json:"percent,omitempty"`
+	Ticks    int      `json:"ticks,omitempty"`
+	Duration Duration `json:"duration,omitempty"`
+}
+
+// Duration is time.Duration written as "30s" in scenario files
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.New(`duration must be a string like "30s"`)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// ResolveScenarioFile returns path of the scenario file inside SCENARIO_DIR (current directory by default)
+func ResolveScenarioFile(name string) (string, error) {
+	return resolveFile("SCENARIO_DIR", name)
+}
+
+// LoadScenario reads and validates scenario file, event symbols must be active
+func LoadScenario(path string, symbols domain.SymbolRegistry) (*Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	scenario := &Scenario{}
+	if err := json.Unmarshal(b, scenario); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+
+	if err := scenario.Validate(symbols); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+	return scenario, nil
+}
+
+// Validate checks events parameters
+func (s *Scenario) Validate(symbols domain.SymbolRegistry) error {
+	if len(s.Events) == 0 {
+		return errors.New("scenario has no events")
+	}
+
+	for i, event := range s.Events {
+		if event.At < 0 || event.Duration < 0 {
+			return fmt.Errorf("event %d: negative time", i)
+		}
+		if event.Symbol != "" && !symbols.Active(event.Symbol) {
+			return fmt.Errorf("event %d: unknown symbol %s", i, event.Symbol)
+		}
+
+		switch event.Type {
+		case FlashCrash:
+			if event.Percent <= 0 || event.Percent >= 100 {
+				return fmt.Errorf("event %d: crash percent must be between 0 and 100", i)
+			}
+		case ExchangeSilence:
+			if event.Exchange == "" || event.Duration == 0 {
+				return fmt.Errorf("event %d: silence needs exchange and duration", i)
+			}
+		case Divergence:
+			if event.Exchange == "" || event.Percent == 0 || event.Percent <= -100 {
+				return fmt.Errorf("event %d: divergence needs exchange and percent above -100", i)
+			}
+		case Burst:
+			if event.Ticks <= 0 || event.Duration == 0 {
+				return fmt.Errorf("event %d: burst needs ticks and duration", i)
+			}
+		default:
+			return fmt.Errorf("event %d: unknown type %q", i, event.Type)
+		}
+	}
+
+	if s.Loop && s.length() == 0 {
+		return errors.New("looped scenario must last longer than zero")
+	}
+	return nil
+}
+
+// Time when the last event ends
+func (s *Scenario) length() time.Duration {
+	var length time.Duration
+	for _, event := range s.Events {
+		length = max(length, time.Duration(event.At+event.Duration))
+	}
+	return length
+}
+
+// Position of the event at scenario time, progress is in [0, 1) while the event is active
+func (e ScenarioEvent) progress(elapsed time.Duration) (float64, bool) {
+	at, duration := time.Duration(e.At), time.Duration(e.Duration)
+	if elapsed < at {
+		return 0, false
+	}
+	if duration == 0 {
+		return 0, true
+	}
+	if elapsed >= at+duration {
+		return 0, false
+	}
+	return float64(elapsed-at) / float64(duration), true
+}
+
+func (e ScenarioEvent) matches(exchange, symbol string) bool {
+	return (e.Exchange == "" || e.Exchange == exchange) && (e.Symbol == "" || e.Symbol == symbol)
+}
+
+// LoadEnvScenario loads scenario named by TEST_SCENARIO, returns nil if it is not set
+func LoadEnvScenario(symbols domain.SymbolRegistry) (*Scenario, error) {
+	name := os.Getenv("TEST_SCENARIO")
+	if name == "" {
+		return nil, nil
+	}
+
+	path, err := ResolveScenarioFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return LoadScenario(path, symbols)
+}
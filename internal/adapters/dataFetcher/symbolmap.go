@@ -0,0 +1,65 @@
+package datafetcher
+
+import (
+	"log/slog"
+	"marketflow/internal/domain"
+	"strings"
+	"sync"
+)
+
+// Separators removed from native symbols, "btc-usdt" and "BTC/USDT" are BTCUSDT
+var symbolSeparators = strings.NewReplacer("-", "", "_", "", "/", "", ":", "", " ", "")
+
+// Limit of distinct unmapped symbols logged per exchange, all of them are counted in the quarantine
+const maxLoggedUnmapped = 100
+
+// SymbolMapper converts native symbols of one exchange to the canonical symbols of the registry
+type SymbolMapper struct {
+	exchange string
+	aliases  map[string]string // native -> canonical
+	natives  map[string]string // canonical -> native
+	symbols  domain.SymbolRegistry
+
+	mu     sync.Mutex
+	logged map[string]bool // unmapped native symbols which are already logged, up to maxLoggedUnmapped
+	muted  bool            // the limit is reached, unmapped symbols are not logged anymore
+}
+
+func NewSymbolMapper(exchange string, aliases, natives map[string]string, symbols domain.SymbolRegistry) *SymbolMapper {
+	return &SymbolMapper{exchange: exchange, aliases: aliases, natives: natives, symbols: symbols, logged: make(map[string]bool)}
+}
+
+// Map returns canonical symbol: the configured alias or the normalized native symbol if the registry has it
+func (m *SymbolMapper) Map(native string) (string, bool) {
+	upper := strings.ToUpper(strings.TrimSpace(native))
+	if canonical, ok := m.aliases[upper]; ok {
+		return canonical, true
+	}
+
+	normalized := symbolSeparators.Replace(upper)
+	if normalized != "" && m.symbols.Observe(normalized) {
+		return normalized, true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.logged[native] || m.muted {
+		return "", false
+	}
+	if len(m.logged) == maxLoggedUnmapped {
+		m.muted = true
+		slog.Warn("Too many unmapped symbols on exchange, the next ones are only counted", "exchange", m.exchange)
+		return "", false
+	}
+	m.logged[native] = true
+	slog.Warn("Unmapped symbol on exchange, add it to the symbol aliases", "exchange", m.exchange, "symbol", native)
+	return "", false
+}
+
+// Native returns symbol of the exchange for the canonical one, it is used in subscription messages
+func (m *SymbolMapper) Native(canonical string) string {
+	if native, ok := m.natives[canonical]; ok {
+		return native
+	}
+	return canonical
+}
@@ -2,31 +2,65 @@ package datafetcher
 
 import (
 	"marketflow/internal/domain"
-	"math/rand"
+	"sort"
+	"sync"
 	"time"
 )
 
 type TestMode struct {
-	stop chan struct{}
+	stop       chan struct{}
+	stopOnce   sync.Once
+	scenario   *Scenario
+	staleAfter time.Duration
+	quarantine domain.TickQuarantine
+	symbols    domain.SymbolRegistry
+	push       *PushIngest
+
+	mu        sync.Mutex
+	exchanges []string
+	generator *MarketGenerator
 }
 
 var _ domain.DataFetcher = (*TestMode)(nil)
 
-func NewTestModeFetcher() *TestMode {
-	return &TestMode{stop: make(chan struct{})}
+// NewTestModeFetcher creates synthetic datafetcher, the scenario events are executed by the generator (scenario could be nil)
+func NewTestModeFetcher(scenario *Scenario, quarantine domain.TickQuarantine, symbols domain.SymbolRegistry, push *PushIngest) *TestMode {
+	return &TestMode{
+		stop:       make(chan struct{}),
+		scenario:   scenario,
+		staleAfter: LoadStaleAfter(),
+		quarantine: quarantine,
+		symbols:    symbols,
+		push:       push,
+	}
 }
 
 func (m *TestMode) SetupDataFetcher() (chan map[string]domain.ExchangeData, chan []domain.Data, error) {
 	rawFlow := make(chan []domain.Data, 100)
 
-	pairs := []string{"BTCUSDT", "DOGEUSDT", "TONUSDT", "SOLUSDT", "ETHUSDT"}
-	exchanges := []string{"Exchange1", "Exchange2", "Exchange3"}
-	basePrices := map[string]float64{
-		"BTCUSDT": 60000.0, "DOGEUSDT": 0.15, "TONUSDT": 5.0, "SOLUSDT": 150.0, "ETHUSDT": 3000.0,
+	exchanges := ExchangeNames(LoadExchangeConfigs())
+	if len(exchanges) == 0 {
+		exchanges = []string{"Exchange1", "Exchange2", "Exchange3"}
+	}
+
+	// Prices are generated by the configured stochastic model, see LoadTestModelConfig,
+	// symbols added to the registry later are generated from their next batch
+	generator, err := NewMarketGenerator(LoadTestModelConfig(m.symbols.List()), exchanges, m.symbols)
+	if err != nil {
+		return nil, nil, err
 	}
+	if m.scenario != nil {
+		generator.SetScenario(m.scenario)
+	}
+
+	m.mu.Lock()
+	m.exchanges = exchanges
+	m.generator = generator
+	m.mu.Unlock()
 
 	go func() {
-		ticker := time.NewTicker(1000 * time.Millisecond)
+		interval := 1000 * time.Millisecond
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
 		for {
@@ -34,31 +68,13 @@ func (m *TestMode) SetupDataFetcher() (chan map[string]domain.ExchangeData, chan
 			case <-m.stop:
 				close(rawFlow)
 				return
-			case <-ticker.C:
-				var rawData []domain.Data
-				now := time.Now()
-
-				for i := 0; i < len(exchanges); i++ {
-					ex := exchanges[rand.Intn(len(exchanges))]
-					for _, pair := range pairs {
-						// Generate random price fluctuation (±15%)
-						price := basePrices[pair] * (1 + (rand.Float64()-0.5)*0.3)
-						rawData = append(rawData, domain.Data{
-							ExchangeName: ex,
-							Symbol:       pair,
-							Price:        price,
-							Timestamp:    now.UnixNano() / int64(time.Millisecond),
-						})
-					}
-				}
-
-				rawFlow <- rawData
-
+			case now := <-ticker.C:
+				rawFlow <- generator.Batch(now, interval)
 			}
 		}
 	}()
 
-	aggregatedCh, rawCh := Aggregate(rawFlow)
+	aggregatedCh, rawCh := IngestPipeline(rawFlow, m.quarantine, m.symbols, m.push)
 	return aggregatedCh, rawCh, nil
 }
 
@@ -92,10 +108,50 @@ func AggregateFromTestMode(input chan []domain.Data) (chan map[string]domain.Exc
 	return aggregated, raw
 }
 
+// Synthetic feeds are unhealthy only while they are silenced by the scenario
 func (m *TestMode) CheckHealth() error {
-	return nil
+	return checkStatuses(m.ExchangeStatuses())
+}
+
+// Returns reports of the synthetic exchanges, feeds are stale after EXCHANGE_STALE_AFTER without ticks
+func (m *TestMode) ExchangeStatuses() []domain.ExchangeStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.generator == nil {
+		return nil
+	}
+
+	lastTicks, startedAt := m.generator.LastTicks()
+	statuses := make([]domain.ExchangeStatus, 0, len(m.exchanges))
+	for _, ex := range m.exchanges {
+		status := domain.ExchangeStatus{
+			Name:      ex,
+			Address:   "synthetic",
+			State:     domain.ExchangeStreaming,
+			LastTicks: lastTicks[ex],
+		}
+
+		for symbol, at := range lastTicks[ex] {
+			if at.After(status.LastMessage) {
+				status.LastMessage = at
+			}
+			if m.staleAfter != 0 && time.Since(at) > m.staleAfter {
+				status.StaleSymbols = append(status.StaleSymbols, symbol)
+			}
+		}
+		sort.Strings(status.StaleSymbols)
+
+		silentSince := status.LastMessage
+		if silentSince.IsZero() {
+			silentSince = startedAt
+		}
+		status.Stale = m.staleAfter != 0 && time.Since(silentSince) > m.staleAfter
+
+		statuses = append(statuses, status)
+	}
+	return statuses
 }
 
 func (m *TestMode) Close() {
-	close(m.stop)
+	m.stopOnce.Do(func() { close(m.stop) })
 }
@@ -0,0 +1,51 @@
+package datafetcher
+
+import (
+	"bufio"
+	"net"
+	"time"
+)
+
+// Transport opens connections to the exchange feed, the supervisor redials it on every reconnect
+type Transport interface {
+	Dial() (Feed, error)
+}
+
+// Feed is one open connection to the exchange
+type Feed interface {
+	// Read sends messages framed by split to emit until the connection is broken or emit returns false
+	Read(split bufio.SplitFunc, emit func(msg []byte) bool) error
+	Close() error
+}
+
+// TCP stream of the exchange, messages are framed by the decoder
+type tcpTransport struct {
+	address string
+}
+
+func (t tcpTransport) Dial() (Feed, error) {
+	conn, err := net.DialTimeout("tcp", t.address, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return tcpFeed{conn}, nil
+}
+
+type tcpFeed struct {
+	net.Conn
+}
+
+func (f tcpFeed) Read(split bufio.SplitFunc, emit func(msg []byte) bool) error {
+	return scanMessages(bufio.NewScanner(f.Conn), split, emit)
+}
+
+func scanMessages(scanner *bufio.Scanner, split bufio.SplitFunc, emit func(msg []byte) bool) error {
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	scanner.Split(split)
+	for scanner.Scan() {
+		if !emit(scanner.Bytes()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
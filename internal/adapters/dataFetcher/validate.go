@@ -0,0 +1,136 @@
+package datafetcher
+
+import (
+	"marketflow/internal/domain"
+	"math"
+	"sort"
+	"time"
+)
+
+// Limit of recent prices kept per symbol for the median
+const maxMedianPoints = 500
+
+type pricePoint struct {
+	price float64
+	at    time.Time
+}
+
+// TickValidator rejects ticks with invalid prices, timestamps too far in the future, symbols which are not
+// active in the registry and prices too far from the rolling cross-exchange median of the symbol
+type TickValidator struct {
+	cfg        ValidatorConfig
+	quarantine domain.TickQuarantine
+	symbols    domain.SymbolRegistry
+	prices     map[string][]pricePoint // recent prices of all exchanges by symbol
+}
+
+func NewTickValidator(cfg ValidatorConfig, quarantine domain.TickQuarantine, symbols domain.SymbolRegistry) *TickValidator {
+	return &TickValidator{cfg: cfg, quarantine: quarantine, symbols: symbols, prices: make(map[string][]pricePoint)}
+}
+
+// Check returns the rejection reason, empty reason means the tick is valid
+func (v *TickValidator) Check(tick domain.Data, now time.Time) string {
+	if math.IsNaN(tick.Price) || math.IsInf(tick.Price, 0) || tick.Price <= 0 {
+		return domain.ReasonInvalidPrice
+	}
+
+	if invalidQuote(tick) {
+		return domain.ReasonInvalidQuote
+	}
+
+	// A future event time would move the exchange watermark past all the following ticks
+	if tick.Timestamp > now.Add(v.cfg.MaxSkew).UnixMilli() {
+		return domain.ReasonFuture
+	}
+
+	// Unknown symbols could be discovered by the registry
+	if !v.symbols.Observe(tick.Symbol) {
+		return domain.ReasonUnknownSymbol
+	}
+
+	// Old prices are out of the rolling window
+	points := v.prices[tick.Symbol]
+	cutoff := now.Add(-v.cfg.Window)
+	i := 0
+	for i < len(points) && points[i].at.Before(cutoff) {
+		i++
+	}
+	if over := len(points) - i - maxMedianPoints + 1; over > 0 {
+		i += over
+	}
+	points = points[i:]
+
+	reason := ""
+	if len(points) >= v.cfg.MinSamples {
+		median := medianPrice(points)
+		if math.Abs(tick.Price-median) > median*v.cfg.MaxDeviation {
+			reason = domain.ReasonOutlier
+		}
+	}
+
+	// Outliers stay in the window too: if every exchange moves, the median follows them
+	v.prices[tick.Symbol] = append(points, pricePoint{price: tick.Price, at: now})
+	return reason
+}
+
+// Filter returns valid ticks of the batch, rejected ones go to the quarantine
+func (v *TickValidator) Filter(batch []domain.Data, now time.Time) []domain.Data {
+	valid := make([]domain.Data, 0, len(batch))
+	for _, tick := range batch {
+		reason := v.Check(tick, now)
+		if reason == "" {
+			valid = append(valid, tick)
+			continue
+		}
+
+		if v.quarantine == nil {
+			continue
+		}
+		if v.cfg.Quarantine {
+			v.quarantine.Put(tick, reason)
+		} else {
+			v.quarantine.Count(tick.ExchangeName, reason)
+		}
+	}
+	return valid
+}
+
+// ValidateTicks is a pipeline stage which passes only valid ticks of every batch
+func ValidateTicks(in chan []domain.Data, v *TickValidator) chan []domain.Data {
+	out := make(chan []domain.Data)
+
+	go func() {
+		defer close(out)
+		for batch := range in {
+			if valid := v.Filter(batch, time.Now()); len(valid) != 0 {
+				out <- valid
+			}
+		}
+	}()
+
+	return out
+}
+
+// Optional fields are valid when they are missing (zero) or positive, bid can't be above ask
+func invalidQuote(tick domain.Data) bool {
+	for _, val := range [3]float64{tick.Quantity, tick.Bid, tick.Ask} {
+		if math.IsNaN(val) || math.IsInf(val, 0) || val < 0 {
+			return true
+		}
+	}
+	return tick.Bid > 0 && tick.Ask > 0 && tick.Bid > tick.Ask
+}
+
+func medianPrice(points []pricePoint) float64 {
+	prices := make([]float64, len(points))
+	for i, p := range points {
+		prices[i] = p.price
+	}
+	sort.Float64s(prices)
+
+	mid := len(prices) / 2
+	if len(prices)%2 == 0 {
+		return (prices[mid-1] + prices[mid]) / 2
+	}
+	return prices[mid]
+}
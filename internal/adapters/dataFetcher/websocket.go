@@ -0,0 +1,200 @@
+package datafetcher
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"marketflow/internal/domain"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket feed of the exchange
+//
+// After every dial the subscription message is sent, a message with {symbol} or {symbol_lower}
+// is sent once per active symbol with its native name. Symbols activated in the registry later
+// are subscribed within symbolsCheckInterval, retired ones stay subscribed and their ticks are
+// rejected by the registry. The client pings the exchange every
+// pingInterval and drops the connection when nothing (pong or data) arrives for two intervals.
+// Pings of the exchange are answered by the default gorilla handler
+type wsTransport struct {
+	url          string
+	subscribe    string
+	pingInterval time.Duration
+	symbols      domain.SymbolRegistry
+	mapper       *SymbolMapper
+}
+
+// How often the registry is checked for symbols which are not subscribed yet
+var symbolsCheckInterval = time.Second
+
+func (t wsTransport) Dial() (Feed, error) {
+	dialer := websocket.Dialer{HandshakeTimeout: 5 * time.Second}
+	conn, _, err := dialer.Dial(t.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &wsFeed{conn: conn, pongWait: 2 * t.pingInterval, done: make(chan struct{}), subscribed: make(map[string]bool)}
+	if err := feed.subscribe(t.subscriptions()); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(feed.pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(feed.pongWait))
+	})
+	go feed.keepalive(t.pingInterval)
+	if t.perSymbol() {
+		go feed.resubscribe(t, symbolsCheckInterval)
+	}
+	return feed, nil
+}
+
+// Subscription message is sent once per symbol
+func (t wsTransport) perSymbol() bool {
+	return strings.Contains(t.subscribe, "{symbol}") || strings.Contains(t.subscribe, "{symbol_lower}")
+}
+
+// Returns subscription messages of the active symbols
+func (t wsTransport) subscriptions() []string {
+	if t.subscribe == "" {
+		return nil
+	}
+	if !t.perSymbol() {
+		return []string{t.subscribe}
+	}
+
+	msgs := make([]string, 0)
+	for _, symbol := range t.symbols.List() {
+		native := symbol
+		if t.mapper != nil {
+			native = t.mapper.Native(symbol)
+		}
+		msgs = append(msgs, strings.NewReplacer("{symbol}", native, "{symbol_lower}", strings.ToLower(native)).Replace(t.subscribe))
+	}
+	return msgs
+}
+
+type wsFeed struct {
+	conn     *websocket.Conn
+	pongWait time.Duration
+
+	writeMu    sync.Mutex      // gorilla connections support one concurrent writer
+	subscribed map[string]bool // sent subscription messages, used by the dial and then by resubscribe only
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// Every WebSocket message is framed by split, so a message could carry a batch of ticks
+func (f *wsFeed) Read(split bufio.SplitFunc, emit func(msg []byte) bool) error {
+	for {
+		_, msg, err := f.conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-f.done:
+				return nil
+			default:
+			}
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return nil
+			}
+			return err
+		}
+		f.conn.SetReadDeadline(time.Now().Add(f.pongWait))
+
+		stopped := false
+		err = scanMessages(bufio.NewScanner(bytes.NewReader(msg)), split, func(msg []byte) bool {
+			stopped = !emit(msg)
+			return !stopped
+		})
+		if stopped {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Pings the exchange until the feed is closed
+func (f *wsFeed) keepalive(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-t.C:
+			if err := f.write(websocket.PingMessage, nil); err != nil {
+				f.Close()
+				return
+			}
+		}
+	}
+}
+
+// Subscribes symbols activated in the registry after the dial until the feed is closed,
+// the feed is closed if a message can't be sent, so the supervisor reconnects and subscribes again
+func (f *wsFeed) resubscribe(t wsTransport, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-ticker.C:
+			if err := f.subscribe(t.subscriptions()); err != nil {
+				f.Close()
+				return
+			}
+		}
+	}
+}
+
+// Sends subscription messages which were not sent on this connection
+func (f *wsFeed) subscribe(msgs []string) error {
+	for _, msg := range msgs {
+		if f.subscribed[msg] {
+			continue
+		}
+		if err := f.write(websocket.TextMessage, []byte(msg)); err != nil {
+			return err
+		}
+		f.subscribed[msg] = true
+	}
+	return nil
+}
+
+func (f *wsFeed) write(messageType int, data []byte) error {
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	switch messageType {
+	case websocket.PingMessage:
+		return f.conn.WriteControl(messageType, data, deadline)
+	case websocket.CloseMessage:
+		// Close could be called under the exchange lock, the frame is best effort
+		return f.conn.WriteControl(messageType, data, time.Now().Add(time.Second))
+	}
+	f.conn.SetWriteDeadline(deadline)
+	return f.conn.WriteMessage(messageType, data)
+}
+
+// Close sends close frame and closes the connection, repeated calls do nothing
+func (f *wsFeed) Close() error {
+	var err error
+	f.closeOnce.Do(func() {
+		close(f.done)
+		f.write(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		err = f.conn.Close()
+	})
+	return err
+}
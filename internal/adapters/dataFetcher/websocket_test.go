@@ -0,0 +1,232 @@
+package datafetcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"marketflow/internal/adapters/symbols"
+	"marketflow/internal/domain"
+
+	"github.com/gorilla/websocket"
+)
+
+// Exchange stub, every connection is passed to handle after the upgrade
+func wsServer(t *testing.T, handle func(conn *websocket.Conn)) (*httptest.Server, string) {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func testRegistry(t *testing.T, list string) *symbols.Registry {
+	t.Helper()
+	t.Setenv("SYMBOLS", list)
+	t.Setenv("SYMBOL_AUTO_DISCOVER", "false")
+	return symbols.NewRegistry(nil)
+}
+
+func TestWSTransportSubscribe(t *testing.T) {
+	defer func(interval time.Duration) { symbolsCheckInterval = interval }(symbolsCheckInterval)
+	symbolsCheckInterval = 20 * time.Millisecond
+
+	received := make(chan string, 10)
+	_, url := wsServer(t, func(conn *websocket.Conn) {
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	})
+
+	registry := testRegistry(t, "BTCUSDT,ETHUSDT")
+	aliases, natives := parseAliases("Exchange1", "XBTUSDT=BTCUSDT,XBT-USDT=BTCUSDT")
+	transport := wsTransport{
+		url:          url,
+		subscribe:    `{"op":"subscribe","channel":"{symbol_lower}@trade"}`,
+		pingInterval: time.Second,
+		symbols:      registry,
+		mapper:       NewSymbolMapper("Exchange1", aliases, natives, registry),
+	}
+
+	feed, err := transport.Dial()
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer feed.Close()
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-received:
+			if got != want {
+				t.Fatalf("subscription = %s, want %s", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("subscription %s is not sent", want)
+		}
+	}
+
+	// The first alias of the canonical symbol is subscribed
+	expect(`{"op":"subscribe","channel":"xbtusdt@trade"}`)
+	expect(`{"op":"subscribe","channel":"ethusdt@trade"}`)
+
+	if _, err := registry.Add("SOLUSDT"); err != nil {
+		t.Fatalf("add symbol: %v", err)
+	}
+	expect(`{"op":"subscribe","channel":"solusdt@trade"}`)
+
+	select {
+	case got := <-received:
+		t.Fatalf("unexpected message %s", got)
+	case <-time.After(5 * symbolsCheckInterval):
+	}
+}
+
+func TestWSFeedPingTimeout(t *testing.T) {
+	tick := `{"symbol":"BTCUSDT","price":100}` + "\n"
+
+	tests := []struct {
+		name    string
+		answer  bool // the exchange reads the connection, so pings are answered by pongs
+		timeout bool
+	}{
+		{name: "pongs keep silent feed", answer: true},
+		{name: "no pongs", timeout: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			_, url := wsServer(t, func(conn *websocket.Conn) {
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(tick)); err != nil {
+					return
+				}
+				if !tt.answer {
+					<-release
+					return
+				}
+				for {
+					if _, _, err := conn.ReadMessage(); err != nil {
+						return
+					}
+				}
+			})
+			defer close(release)
+
+			transport := wsTransport{url: url, pingInterval: 200 * time.Millisecond, symbols: testRegistry(t, "BTCUSDT")}
+			feed, err := transport.Dial()
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer feed.Close()
+
+			messages := make(chan string, 1)
+			done := make(chan error, 1)
+			go func() {
+				done <- feed.Read(jsonDecoder{}.Split, func(msg []byte) bool {
+					messages <- string(msg)
+					return true
+				})
+			}()
+
+			select {
+			case msg := <-messages:
+				if msg != strings.TrimSpace(tick) {
+					t.Fatalf("message = %s, want %s", msg, strings.TrimSpace(tick))
+				}
+			case <-time.After(time.Second):
+				t.Fatal("tick is not read")
+			}
+
+			select {
+			case err := <-done:
+				if !tt.timeout {
+					t.Fatalf("read finished on answered feed: %v", err)
+				}
+				if err == nil {
+					t.Fatal("read finished without timeout error")
+				}
+			case <-time.After(1500 * time.Millisecond): // the feed without pongs is dropped after 400ms
+				if tt.timeout {
+					t.Fatal("read is not timed out without pongs")
+				}
+			}
+		})
+	}
+}
+
+func TestWSExchangeReconnect(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		connections int
+	)
+	_, url := wsServer(t, func(conn *websocket.Conn) {
+		mu.Lock()
+		connections++
+		first := connections == 1
+		mu.Unlock()
+
+		if _, _, err := conn.ReadMessage(); err != nil { // subscription
+			return
+		}
+		if first {
+			return // the exchange drops the first connection
+		}
+
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"symbol":"BTCUSDT","price":100}`+"\n")); err != nil {
+			return
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	registry := testRegistry(t, "BTCUSDT")
+	exch := GenerateExchange("Exchange1", url, BackoffConfig{Min: 10 * time.Millisecond, Max: 10 * time.Millisecond}, 0)
+	exch.transport = wsTransport{url: url, subscribe: `{"subscribe":"{symbol}"}`, pingInterval: time.Second, symbols: registry}
+	if err := exch.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go exch.Supervise(wg)
+
+	select {
+	case msg := <-exch.messageChan:
+		if msg != `{"symbol":"BTCUSDT","price":100}` {
+			t.Fatalf("message = %s", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message after reconnect")
+	}
+
+	exch.Stop()
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if connections != 2 {
+		t.Fatalf("connections = %d, want 2", connections)
+	}
+	if status := exch.Status(); status.Reconnects != 1 || status.State != domain.ExchangeStopped {
+		t.Fatalf("reconnects = %d, state = %s, want 1 and stopped", status.Reconnects, status.State)
+	}
+}
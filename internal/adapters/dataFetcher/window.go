@@ -0,0 +1,267 @@
+package datafetcher
+
+import (
+	"marketflow/internal/domain"
+	"math"
+	"sort"
+	"time"
+)
+
+// Accumulated ticks of one event-time window
+type window struct {
+	data   map[string]domain.ExchangeData
+	sums   map[string]float64
+	counts map[string]int
+	quotes map[string]quoteSums
+	prices map[string][]timedPrice // for the time-weighted average
+}
+
+// Tick price at its event time, unix ms
+type timedPrice struct {
+	price float64
+	at    int64
+}
+
+// Sums of ticks which have both bid and ask
+type quoteSums struct {
+	bid, ask float64
+	count    int
+}
+
+// Event time progress of one exchange
+type eventSource struct {
+	maxEvent int64     // the newest event time, unix ms
+	lastSeen time.Time // arrival time of the last tick
+}
+
+// EventTimeWindows buckets ticks by the exchange timestamp instead of arrival time
+//
+// The watermark is the slowest active exchange event time minus WatermarkDelay,
+// a window is closed and emitted when its end is not after the watermark.
+// Ticks of already closed windows are handled by the late policy
+type EventTimeWindows struct {
+	cfg        WindowConfig
+	quarantine domain.TickQuarantine
+	open       map[int64]*window // by window start, unix ms
+	sources    map[string]*eventSource
+	watermark  int64
+}
+
+func NewEventTimeWindows(cfg WindowConfig, quarantine domain.TickQuarantine) *EventTimeWindows {
+	return &EventTimeWindows{
+		cfg:        cfg,
+		quarantine: quarantine,
+		open:       make(map[int64]*window),
+		sources:    make(map[string]*eventSource),
+		watermark:  math.MinInt64,
+	}
+}
+
+// Add puts the batch ticks into their windows, returns corrections of closed windows made by late ticks
+func (w *EventTimeWindows) Add(batch []domain.Data, now time.Time) []map[string]domain.ExchangeData {
+	size := w.cfg.Size.Milliseconds()
+	late := make(map[int64]*window)
+
+	for _, data := range batch {
+		eventTime := data.Timestamp
+		if eventTime == 0 {
+			eventTime = now.UnixMilli()
+		}
+
+		src, ok := w.sources[data.ExchangeName]
+		if !ok {
+			src = &eventSource{maxEvent: eventTime}
+			w.sources[data.ExchangeName] = src
+		}
+		if eventTime > src.maxEvent {
+			src.maxEvent = eventTime
+		}
+		src.lastSeen = now
+
+		start := eventTime - eventTime%size
+		if start+size > w.watermark {
+			w.window(w.open, start).add(data, eventTime)
+			continue
+		}
+
+		// Window of the tick is already closed
+		switch {
+		case w.cfg.LatePolicy == LateSide:
+			if w.quarantine != nil {
+				w.quarantine.Put(data, domain.ReasonLate)
+			}
+		case w.cfg.LatePolicy == LateMerge && w.watermark-(start+size) <= w.cfg.AllowedLateness.Milliseconds():
+			w.window(late, start).add(data, eventTime)
+		default:
+			if w.quarantine != nil {
+				w.quarantine.Count(data.ExchangeName, domain.ReasonLate)
+			}
+		}
+	}
+
+	return w.emit(late, math.MaxInt64)
+}
+
+// Advance moves the watermark and returns closed windows in event time order
+func (w *EventTimeWindows) Advance(now time.Time) []map[string]domain.ExchangeData {
+	watermark := int64(math.MaxInt64)
+	var newest int64 = math.MinInt64
+	active := false
+
+	for _, src := range w.sources {
+		if src.maxEvent > newest {
+			newest = src.maxEvent
+		}
+		if now.Sub(src.lastSeen) > w.cfg.IdleTimeout {
+			continue
+		}
+		active = true
+		if src.maxEvent < watermark {
+			watermark = src.maxEvent
+		}
+	}
+
+	switch {
+	case active:
+		watermark -= w.cfg.WatermarkDelay.Milliseconds()
+	case len(w.sources) != 0:
+		// All exchanges are idle: nothing holds the open windows anymore
+		watermark = newest + w.cfg.Size.Milliseconds()
+	default:
+		return nil
+	}
+
+	if watermark > w.watermark {
+		w.watermark = watermark
+	}
+	return w.emit(w.open, w.watermark)
+}
+
+// Flush returns all open windows, it is used when the input is closed
+func (w *EventTimeWindows) Flush() []map[string]domain.ExchangeData {
+	return w.emit(w.open, math.MaxInt64)
+}
+
+// Removes windows with end not after the watermark from windows and returns their aggregates
+func (w *EventTimeWindows) emit(windows map[int64]*window, watermark int64) []map[string]domain.ExchangeData {
+	size := w.cfg.Size.Milliseconds()
+
+	starts := make([]int64, 0)
+	for start := range windows {
+		if start+size <= watermark || watermark == math.MaxInt64 {
+			starts = append(starts, start)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	result := make([]map[string]domain.ExchangeData, 0, len(starts))
+	for _, start := range starts {
+		result = append(result, windows[start].result(start, size))
+		delete(windows, start)
+	}
+	return result
+}
+
+func (w *EventTimeWindows) window(windows map[int64]*window, start int64) *window {
+	win, ok := windows[start]
+	if !ok {
+		win = &window{
+			data:   make(map[string]domain.ExchangeData),
+			sums:   make(map[string]float64),
+			counts: make(map[string]int),
+			quotes: make(map[string]quoteSums),
+			prices: make(map[string][]timedPrice),
+		}
+		windows[start] = win
+	}
+	return win
+}
+
+// Adds tick to the exchange and "All" aggregates, ticks without origin are live
+func (win *window) add(data domain.Data, eventTime int64) {
+	origin := data.Origin
+	if origin == "" {
+		origin = domain.OriginLive
+	}
+
+	keys := [2][2]string{
+		{data.ExchangeName + " " + data.Symbol, data.ExchangeName}, // by exchange
+		{"All " + data.Symbol, "All"},                              // by all exchanges
+	}
+
+	for _, key := range keys {
+		val, exists := win.data[key[0]]
+		if !exists {
+			val = domain.ExchangeData{
+				Exchange:  key[1],
+				Pair_name: data.Symbol,
+				Min_price: math.Inf(1),
+				Max_price: math.Inf(-1),
+			}
+		}
+
+		if data.Price < val.Min_price {
+			val.Min_price = data.Price
+		}
+		if data.Price > val.Max_price {
+			val.Max_price = data.Price
+		}
+		val.Origin = domain.MergeOrigin(val.Origin, origin)
+		val.Volume += data.Quantity
+
+		if data.Bid > 0 && data.Ask > 0 {
+			q := win.quotes[key[0]]
+			q.bid += data.Bid
+			q.ask += data.Ask
+			q.count++
+			win.quotes[key[0]] = q
+		}
+
+		win.sums[key[0]] += data.Price
+		win.counts[key[0]]++
+		win.prices[key[0]] = append(win.prices[key[0]], timedPrice{price: data.Price, at: eventTime})
+
+		win.data[key[0]] = val
+	}
+}
+
+// Counts average prices and their components, timestamp of all aggregates is the window start (unix ms)
+//
+// For the time-weighted average every price is held until the next tick of the window or the window end
+func (win *window) result(start, size int64) map[string]domain.ExchangeData {
+	for key, ed := range win.data {
+		if count := win.counts[key]; count > 0 {
+			ed.Average_price = win.sums[key] / float64(count)
+			ed.Price_sum = win.sums[key]
+			ed.Tick_count = int64(count)
+			ed.Weighted_sum, ed.Weighted_time = timeWeighted(win.prices[key], start+size)
+			ed.Timestamp = time.UnixMilli(start)
+			if q := win.quotes[key]; q.count > 0 {
+				ed.Average_bid = q.bid / float64(q.count)
+				ed.Average_ask = q.ask / float64(q.count)
+				ed.Bid_sum, ed.Ask_sum, ed.Quote_count = q.bid, q.ask, int64(q.count)
+			}
+			win.data[key] = ed
+		}
+	}
+	return win.data
+}
+
+// Returns sum of prices multiplied by their holding time and the total holding time, ms
+func timeWeighted(prices []timedPrice, end int64) (float64, int64) {
+	sort.SliceStable(prices, func(i, j int) bool { return prices[i].at < prices[j].at })
+
+	var (
+		sum  float64
+		held int64
+	)
+	for i, p := range prices {
+		until := end
+		if i+1 < len(prices) {
+			until = prices[i+1].at
+		}
+		sum += p.price * float64(until-p.at)
+		held += until - p.at
+	}
+	return sum, held
+}
@@ -0,0 +1,118 @@
+package quarantine
+
+import (
+	"marketflow/internal/domain"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryQuarantine keeps the last rejected ticks in a ring buffer and counts all of them
+type MemoryQuarantine struct {
+	mu     sync.Mutex
+	ticks  []domain.QuarantinedTick
+	next   int
+	full   bool
+	counts map[string]map[string]int64
+}
+
+var _ domain.TickQuarantine = (*MemoryQuarantine)(nil)
+
+// NewMemoryQuarantine keeps QUARANTINE_SIZE last ticks (default 1000)
+func NewMemoryQuarantine() *MemoryQuarantine {
+	size := 1000
+	if val, err := strconv.Atoi(os.Getenv("QUARANTINE_SIZE")); err == nil && val > 0 {
+		size = val
+	}
+
+	return &MemoryQuarantine{
+		ticks:  make([]domain.QuarantinedTick, size),
+		counts: make(map[string]map[string]int64),
+	}
+}
+
+func (q *MemoryQuarantine) Put(tick domain.Data, reason string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.count(tick.ExchangeName, reason)
+
+	kept := domain.QuarantinedTick{Data: tick, Reason: reason, At: time.Now()}
+	kept.RawPrice = sanitize(&kept.Price)
+	kept.RawQuantity = sanitize(&kept.Quantity)
+	kept.RawBid = sanitize(&kept.Bid)
+	kept.RawAsk = sanitize(&kept.Ask)
+
+	q.ticks[q.next] = kept
+	q.next++
+	if q.next == len(q.ticks) {
+		q.next, q.full = 0, true
+	}
+}
+
+func (q *MemoryQuarantine) Count(exchange, reason string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.count(exchange, reason)
+}
+
+// Report returns counters and up to limit newest kept ticks (all reasons if reason is empty, all ticks if limit is 0)
+func (q *MemoryQuarantine) Report(reason string, limit int) domain.QuarantineReport {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	report := domain.QuarantineReport{
+		Counts: make(map[string]map[string]int64, len(q.counts)),
+		Ticks:  make([]domain.QuarantinedTick, 0),
+	}
+
+	for exchange, reasons := range q.counts {
+		report.Counts[exchange] = make(map[string]int64, len(reasons))
+		for r, n := range reasons {
+			if reason == "" || r == reason {
+				report.Counts[exchange][r] = n
+			}
+		}
+	}
+
+	stored := q.next
+	if q.full {
+		stored = len(q.ticks)
+	}
+
+	// From the newest to the oldest
+	for i := 1; i <= stored; i++ {
+		tick := q.ticks[(q.next-i+len(q.ticks))%len(q.ticks)]
+		if reason != "" && tick.Reason != reason {
+			continue
+		}
+		report.Ticks = append(report.Ticks, tick)
+		if limit > 0 && len(report.Ticks) == limit {
+			break
+		}
+	}
+
+	return report
+}
+
+// Should be called under the lock
+func (q *MemoryQuarantine) count(exchange, reason string) {
+	reasons, ok := q.counts[exchange]
+	if !ok {
+		reasons = make(map[string]int64)
+		q.counts[exchange] = reasons
+	}
+	reasons[reason]++
+}
+
+// Replaces NaN or infinite value with 0 and returns it as string, empty string is returned for valid values
+func sanitize(val *float64) string {
+	if !math.IsNaN(*val) && !math.IsInf(*val, 0) {
+		return ""
+	}
+	raw := strconv.FormatFloat(*val, 'f', -1, 64)
+	*val = 0
+	return raw
+}
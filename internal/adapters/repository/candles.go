@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"marketflow/internal/domain"
+	"time"
+)
+
+// Saves candles, a candle of the already stored bucket is merged into it: open and close are taken
+// from the earliest and latest ticks, high, low, ticks and volume are combined
+func (repo *PostgresDatabase) SaveCandles(candles []domain.Candle) error {
+	tx, err := repo.Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO Candles (Exchange, Pair_name, Resolution, StartTime, Open_price, High_price, Low_price, Close_price, Ticks, Volume, Origin, OpenTime, CloseTime)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (Exchange, Pair_name, Resolution, StartTime) DO UPDATE
+		SET Open_price = CASE WHEN EXCLUDED.OpenTime < Candles.OpenTime THEN EXCLUDED.Open_price ELSE Candles.Open_price END,
+		OpenTime = LEAST(Candles.OpenTime, EXCLUDED.OpenTime),
+		Close_price = CASE WHEN EXCLUDED.CloseTime >= Candles.CloseTime THEN EXCLUDED.Close_price ELSE Candles.Close_price END,
+		CloseTime = GREATEST(Candles.CloseTime, EXCLUDED.CloseTime),
+		High_price = GREATEST(Candles.High_price, EXCLUDED.High_price),
+		Low_price = LEAST(Candles.Low_price, EXCLUDED.Low_price),
+		Ticks = Candles.Ticks + EXCLUDED.Ticks,
+		Volume = Candles.Volume + EXCLUDED.Volume,
+		Origin = CASE WHEN Candles.Origin = EXCLUDED.Origin THEN Candles.Origin ELSE 'mixed' END;
+		`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, c := range candles {
+		if _, err := stmt.Exec(c.Exchange, c.Symbol, c.Interval, c.Start, c.Open, c.High, c.Low, c.Close, c.Ticks, c.Volume, originOrLive(c.Origin), c.OpenAt, c.CloseAt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Gets stored candles which start within [from, to) ordered by start
+func (repo *PostgresDatabase) GetCandles(exchange, symbol, interval string, from, to time.Time) ([]domain.Candle, error) {
+	rows, err := repo.Db.Query(`
+		SELECT StartTime, Open_price, High_price, Low_price, Close_price, Ticks, Volume, Origin, OpenTime, CloseTime
+		FROM Candles
+		WHERE Exchange = $1 AND Pair_name = $2 AND Resolution = $3 AND StartTime >= $4 AND StartTime < $5
+		ORDER BY StartTime;
+		`, exchange, symbol, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candles := make([]domain.Candle, 0)
+	for rows.Next() {
+		c := domain.Candle{Exchange: exchange, Symbol: symbol, Interval: interval}
+		if err := rows.Scan(&c.Start, &c.Open, &c.High, &c.Low, &c.Close, &c.Ticks, &c.Volume, &c.Origin, &c.OpenAt, &c.CloseAt); err != nil {
+			return nil, err
+		}
+		c.Start = c.Start.UTC()
+		candles = append(candles, c)
+	}
+	return candles, rows.Err()
+}
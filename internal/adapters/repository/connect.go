@@ -35,6 +35,11 @@ func ConnectDB() *PostgresDatabase {
 		log.Fatalf("Failed to send ping message to the Database %s", err.Error())
 	}
 
+	repo := &PostgresDatabase{Db: db}
+	if err := repo.Migrate(); err != nil {
+		log.Fatalf("Failed to migrate the Database %s", err.Error())
+	}
+
 	slog.Info("Database connection finished...")
-	return &PostgresDatabase{Db: db}
+	return repo
 }
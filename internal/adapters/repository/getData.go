@@ -14,7 +14,7 @@ func (repo *PostgresDatabase) GetLatestDataByExchange(exchange, symbol string) (
 	}
 
 	rows, err := repo.Db.Query(`
-		SELECT Exchange, Pair_name, Price, StoredTime
+		SELECT Exchange, Pair_name, Price, StoredTime, Origin, Quantity, Bid, Ask
 			FROM LatestData
 		WHERE Exchange = $1 AND Pair_name = $2
 		ORDER BY StoredTime DESC
@@ -26,7 +26,7 @@ func (repo *PostgresDatabase) GetLatestDataByExchange(exchange, symbol string) (
 	defer rows.Close()
 
 	if rows.Next() {
-		if err := rows.Scan(&data.ExchangeName, &data.Symbol, &data.Price, &data.Timestamp); err != nil {
+		if err := rows.Scan(&data.ExchangeName, &data.Symbol, &data.Price, &data.Timestamp, &data.Origin, &data.Quantity, &data.Bid, &data.Ask); err != nil {
 			return domain.Data{}, err
 		}
 
@@ -43,7 +43,7 @@ func (repo *PostgresDatabase) GetLatestDataByAllExchanges(symbol string) (domain
 	}
 
 	rows, err := repo.Db.Query(`
-		SELECT Exchange, Pair_name, Price, StoredTime
+		SELECT Exchange, Pair_name, Price, StoredTime, Origin, Quantity, Bid, Ask
 		FROM LatestData
 		WHERE Pair_name = $1
 		ORDER BY StoredTime DESC
@@ -55,7 +55,7 @@ func (repo *PostgresDatabase) GetLatestDataByAllExchanges(symbol string) (domain
 	defer rows.Close()
 
 	if rows.Next() {
-		if err := rows.Scan(&data.ExchangeName, &data.Symbol, &data.Price, &data.Timestamp); err != nil {
+		if err := rows.Scan(&data.ExchangeName, &data.Symbol, &data.Price, &data.Timestamp, &data.Origin, &data.Quantity, &data.Bid, &data.Ask); err != nil {
 			return domain.Data{}, err
 		}
 		return data, nil
@@ -64,79 +64,54 @@ func (repo *PostgresDatabase) GetLatestDataByAllExchanges(symbol string) (domain
 	return domain.Data{}, nil
 }
 
-// Gets the average price data by exchange over all period
-func (repo *PostgresDatabase) GetAveragePriceByExchange(exchange, symbol string) (domain.Data, error) {
-	data := domain.Data{
-		ExchangeName: exchange,
-		Symbol:       symbol,
-	}
-
-	rows, err := repo.Db.Query(`
-	SELECT COALESCE(AVG(Average_price), 0) FROM AggregatedData
+// Components of the average price, rows saved before the tick sums were stored count as one tick
+const averageColumns = `
+	COALESCE(SUM(CASE WHEN Tick_count > 0 THEN Price_sum ELSE Average_price END), 0),
+	COALESCE(SUM(GREATEST(Tick_count, 1)), 0),
+	COALESCE(SUM(Weighted_sum), 0),
+	COALESCE(SUM(Weighted_time), 0),
+	CASE WHEN COUNT(DISTINCT Origin) > 1 THEN 'mixed' ELSE COALESCE(MIN(Origin), '') END`
+
+// Gets the average price components by exchange over all period
+func (repo *PostgresDatabase) GetAveragePriceByExchange(exchange, symbol string) (domain.PriceAverage, error) {
+	return repo.queryAverage(`
+	SELECT `+averageColumns+`
+	FROM AggregatedData
 	WHERE Exchange = $1 AND Pair_name = $2
 	`, exchange, symbol)
-	if err != nil {
-		return domain.Data{}, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		if err := rows.Scan(&data.Price); err != nil {
-			return domain.Data{}, err
-		}
-	}
-
-	return data, nil
 }
 
-// Gets the average price by exchange over all period
-func (repo *PostgresDatabase) GetAveragePriceByAllExchanges(symbol string) (domain.Data, error) {
-	data := domain.Data{
-		ExchangeName: "All",
-		Symbol:       symbol,
-	}
-
-	rows, err := repo.Db.Query(`
-	SELECT COALESCE(AVG(Average_price), 0) from AggregatedData
+// Gets the average price components of all exchanges over all period
+func (repo *PostgresDatabase) GetAveragePriceByAllExchanges(symbol string) (domain.PriceAverage, error) {
+	return repo.queryAverage(`
+	SELECT `+averageColumns+`
+	FROM AggregatedData
 	WHERE Pair_name = $1 AND Exchange = 'All'
 	`, symbol)
-	if err != nil {
-		return domain.Data{}, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		if err := rows.Scan(&data.Price); err != nil {
-			return domain.Data{}, err
-		}
-	}
-	fmt.Println("Debuger: ", data)
-	return data, nil
 }
 
-// Gets the average price within the last {duration}
-func (repo *PostgresDatabase) GetAveragePriceWithDuration(exchange, symbol string, startTime time.Time, duration time.Duration) (domain.Data, error) {
-	data := domain.Data{
-		ExchangeName: exchange,
-		Symbol:       symbol,
-	}
-
-	rows, err := repo.Db.Query(`
-	SELECT COALESCE(AVG(Average_price), 0) FROM AggregatedData
-	WHERE Exchange = $1 AND Pair_name = $2 AND StoredTime BETWEEN $3 and $4
-	`, exchange, symbol, startTime.Add(-duration), startTime)
-	if err != nil {
-		return domain.Data{}, err
-	}
-	defer rows.Close()
+// Gets the average price components within [from, to)
+func (repo *PostgresDatabase) GetAveragePriceInRange(exchange, symbol string, from, to time.Time) (domain.PriceAverage, error) {
+	return repo.queryAverage(`
+	SELECT `+averageColumns+`
+	FROM AggregatedData
+	WHERE Exchange = $1 AND Pair_name = $2 AND StoredTime >= $3 AND StoredTime < $4
+	`, exchange, symbol, from, to)
+}
 
-	for rows.Next() {
-		if err := rows.Scan(&data.Price); err != nil {
-			return domain.Data{}, err
-		}
-	}
+// Gets the average price components of all exchanges within [from, to)
+func (repo *PostgresDatabase) GetAveragePriceByAllExchangesInRange(symbol string, from, to time.Time) (domain.PriceAverage, error) {
+	return repo.queryAverage(`
+	SELECT `+averageColumns+`
+	FROM AggregatedData
+	WHERE Pair_name = $1 AND Exchange = 'All' AND StoredTime >= $2 AND StoredTime < $3
+	`, symbol, from, to)
+}
 
-	return data, nil
+func (repo *PostgresDatabase) queryAverage(query string, args ...any) (domain.PriceAverage, error) {
+	var avg domain.PriceAverage
+	err := repo.Db.QueryRow(query, args...).Scan(&avg.Sum, &avg.Count, &avg.WeightedSum, &avg.WeightedTime, &avg.Origin)
+	return avg, err
 }
 
 // Min by all exchange and all time
@@ -147,7 +122,7 @@ func (repo *PostgresDatabase) GetMinPriceByAllExchanges(symbol string) (domain.D
 	}
 
 	rows, err := repo.Db.Query(`
-SELECT Pair_name, exchange, StoredTime, Min_price
+SELECT Pair_name, exchange, StoredTime, Min_price, Origin
 FROM AggregatedData
 WHERE 
     Pair_name = $1  AND exchange = 'All'
@@ -166,7 +141,7 @@ WHERE
 
 	var t time.Time
 	for rows.Next() {
-		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price); err != nil {
+		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price, &data.Origin); err != nil {
 			return domain.Data{}, err
 		}
 	}
@@ -183,7 +158,7 @@ func (repo *PostgresDatabase) GetMinPriceByExchange(exchange, symbol string) (do
 	}
 
 	rows, err := repo.Db.Query(`
-SELECT Pair_name, exchange, StoredTime, Min_price
+SELECT Pair_name, exchange, StoredTime, Min_price, Origin
 FROM AggregatedData
 WHERE 
     Pair_name = $1  AND exchange = $2
@@ -202,7 +177,7 @@ WHERE
 
 	var t time.Time
 	for rows.Next() {
-		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price); err != nil {
+		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price, &data.Origin); err != nil {
 			return domain.Data{}, err
 		}
 	}
@@ -211,27 +186,27 @@ WHERE
 	return data, nil
 }
 
-// Min by one exchange on period
-func (repo *PostgresDatabase) GetMinPriceByExchangeWithDuration(exchange, symbol string, startTime time.Time, duration time.Duration) (domain.Data, error) {
+// Min by one exchange within [from, to)
+func (repo *PostgresDatabase) GetMinPriceByExchangeInRange(exchange, symbol string, from, to time.Time) (domain.Data, error) {
 	data := domain.Data{
 		ExchangeName: exchange,
 		Symbol:       symbol,
 	}
 
 	rows, err := repo.Db.Query(`
-SELECT Pair_name, exchange, StoredTime, Min_price
+SELECT Pair_name, exchange, StoredTime, Min_price, Origin
 FROM AggregatedData
 WHERE 
-    Pair_name = $1  AND exchange =  $2 AND StoredTime BETWEEN $3 AND $4
+    Pair_name = $1  AND exchange =  $2 AND StoredTime >= $3 AND StoredTime < $4
     AND Min_price = (
         SELECT MIN(Min_price)
         FROM AggregatedData
         WHERE 
             Pair_name = $1
             AND exchange = $2
-            AND StoredTime BETWEEN $3 AND $4
+            AND StoredTime >= $3 AND StoredTime < $4
     );
-	`, symbol, exchange, startTime.Add(-duration), startTime)
+	`, symbol, exchange, from, to)
 	if err != nil {
 		return domain.Data{}, err
 	}
@@ -239,7 +214,7 @@ WHERE
 
 	var t time.Time
 	for rows.Next() {
-		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price); err != nil {
+		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price, &data.Origin); err != nil {
 			return domain.Data{}, err
 		}
 	}
@@ -249,27 +224,27 @@ WHERE
 	return data, nil
 }
 
-// Min by one exchange on period
-func (repo *PostgresDatabase) GetMinPriceByAllExchangesWithDuration(symbol string, startTime time.Time, duration time.Duration) (domain.Data, error) {
+// Min by all exchanges within [from, to)
+func (repo *PostgresDatabase) GetMinPriceByAllExchangesInRange(symbol string, from, to time.Time) (domain.Data, error) {
 	data := domain.Data{
 		ExchangeName: "All",
 		Symbol:       symbol,
 	}
 
 	rows, err := repo.Db.Query(`
-SELECT Pair_name, exchange, StoredTime, Min_price
+SELECT Pair_name, exchange, StoredTime, Min_price, Origin
 FROM AggregatedData
 WHERE 
-    Pair_name = $1  AND exchange = 'All' AND StoredTime BETWEEN $2 AND $3
+    Pair_name = $1  AND exchange = 'All' AND StoredTime >= $2 AND StoredTime < $3
     AND Min_price = (
         SELECT MIN(Min_price)
         FROM AggregatedData
         WHERE 
             Pair_name = $1
             AND exchange = 'All'
-            AND StoredTime BETWEEN $2 AND $3
+            AND StoredTime >= $2 AND StoredTime < $3
     );
-	`, symbol, startTime.Add(-duration), startTime)
+	`, symbol, from, to)
 	if err != nil {
 		return domain.Data{}, err
 	}
@@ -277,7 +252,7 @@ WHERE
 
 	var t time.Time
 	for rows.Next() {
-		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price); err != nil {
+		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price, &data.Origin); err != nil {
 			return domain.Data{}, err
 		}
 	}
@@ -294,7 +269,7 @@ func (repo *PostgresDatabase) GetMaxPriceByAllExchanges(symbol string) (domain.D
 	}
 
 	rows, err := repo.Db.Query(`
-SELECT Pair_name, exchange, StoredTime, Max_price
+SELECT Pair_name, exchange, StoredTime, Max_price, Origin
 FROM AggregatedData
 WHERE 
     Pair_name = $1  AND exchange = 'All'
@@ -314,7 +289,7 @@ WHERE
 
 	var t time.Time
 	for rows.Next() {
-		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price); err != nil {
+		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price, &data.Origin); err != nil {
 			return domain.Data{}, err
 		}
 	}
@@ -332,7 +307,7 @@ func (repo *PostgresDatabase) GetMaxPriceByExchange(exchange, symbol string) (do
 	}
 
 	rows, err := repo.Db.Query(`
-SELECT Pair_name, exchange, StoredTime, Max_price
+SELECT Pair_name, exchange, StoredTime, Max_price, Origin
 FROM AggregatedData
 WHERE 
     Pair_name = $1  AND exchange = $2
@@ -351,7 +326,7 @@ WHERE
 
 	var t time.Time
 	for rows.Next() {
-		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price); err != nil {
+		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price, &data.Origin); err != nil {
 			return domain.Data{}, err
 		}
 	}
@@ -360,27 +335,27 @@ WHERE
 	return data, nil
 }
 
-// Max by one exchange on period
-func (repo *PostgresDatabase) GetMaxPriceByExchangeWithDuration(exchange, symbol string, startTime time.Time, duration time.Duration) (domain.Data, error) {
+// Max by one exchange within [from, to)
+func (repo *PostgresDatabase) GetMaxPriceByExchangeInRange(exchange, symbol string, from, to time.Time) (domain.Data, error) {
 	data := domain.Data{
 		ExchangeName: exchange,
 		Symbol:       symbol,
 	}
 
 	rows, err := repo.Db.Query(`
-SELECT Pair_name, exchange, StoredTime, Max_price
+SELECT Pair_name, exchange, StoredTime, Max_price, Origin
 FROM AggregatedData
 WHERE 
-    Pair_name = $1  AND exchange = $2 AND StoredTime BETWEEN $3 AND $4
+    Pair_name = $1  AND exchange = $2 AND StoredTime >= $3 AND StoredTime < $4
     AND Max_price = (
         SELECT MAX(Max_price)
         FROM AggregatedData
         WHERE 
             Pair_name = $1
             AND exchange = $2
-            AND StoredTime BETWEEN $3 AND $4
+            AND StoredTime >= $3 AND StoredTime < $4
     );
-	`, symbol, exchange, startTime.Add(-duration), startTime)
+	`, symbol, exchange, from, to)
 	if err != nil {
 		return domain.Data{}, err
 	}
@@ -388,7 +363,7 @@ WHERE
 
 	var t time.Time
 	for rows.Next() {
-		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price); err != nil {
+		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price, &data.Origin); err != nil {
 			return domain.Data{}, err
 		}
 	}
@@ -398,28 +373,28 @@ WHERE
 	return data, nil
 }
 
-// Max by all exchange on period
-func (repo *PostgresDatabase) GetMaxPriceByAllExchangesWithDuration(symbol string, startTime time.Time, duration time.Duration) (domain.Data, error) {
+// Max by all exchanges within [from, to)
+func (repo *PostgresDatabase) GetMaxPriceByAllExchangesInRange(symbol string, from, to time.Time) (domain.Data, error) {
 	data := domain.Data{
 		ExchangeName: "All",
 		Symbol:       symbol,
 	}
 
 	rows, err := repo.Db.Query(`
-SELECT Pair_name, exchange, StoredTime, Max_price
+SELECT Pair_name, exchange, StoredTime, Max_price, Origin
 FROM AggregatedData
 WHERE 
-    Pair_name = $1  AND exchange = 'All' AND StoredTime BETWEEN $2 AND $3
+    Pair_name = $1  AND exchange = 'All' AND StoredTime >= $2 AND StoredTime < $3
     AND Max_price = (
         SELECT MAX(Max_price)
         FROM AggregatedData
         WHERE 
             Pair_name = $1
             AND exchange = 'All'
-            AND StoredTime BETWEEN $2 AND $3
+            AND StoredTime >= $2 AND StoredTime < $3
     );
 
-	`, symbol, startTime.Add(-duration), startTime)
+	`, symbol, from, to)
 	if err != nil {
 		return domain.Data{}, err
 	}
@@ -427,7 +402,7 @@ WHERE
 
 	var t time.Time
 	for rows.Next() {
-		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price); err != nil {
+		if err := rows.Scan(&data.Symbol, &data.ExchangeName, &t, &data.Price, &data.Origin); err != nil {
 			return domain.Data{}, err
 		}
 	}
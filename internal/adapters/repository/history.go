@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"marketflow/internal/domain"
+	"time"
+)
+
+// Gets stored aggregated rows within [from, to) which come after the given point in (StoredTime, Data_id) order
+func (repo *PostgresDatabase) GetPriceHistory(exchange, symbol string, from, to time.Time, after domain.PricePoint, limit int) ([]domain.PricePoint, error) {
+	rows, err := repo.Db.Query(`
+		SELECT Data_id, StoredTime, Average_price, Min_price, Max_price, Origin
+		FROM AggregatedData
+		WHERE Exchange = $1 AND Pair_name = $2 AND StoredTime >= $3 AND StoredTime < $4
+			AND (StoredTime, Data_id) > ($5, $6)
+		ORDER BY StoredTime, Data_id
+		LIMIT $7;
+		`, exchange, symbol, from, to, after.Timestamp, after.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]domain.PricePoint, 0, limit)
+	for rows.Next() {
+		var p domain.PricePoint
+		if err := rows.Scan(&p.ID, &p.Timestamp, &p.Average_price, &p.Min_price, &p.Max_price, &p.Origin); err != nil {
+			return nil, err
+		}
+		p.Timestamp = p.Timestamp.UTC()
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// Schema changes made after the first release. init.sql only runs on an empty data directory,
+// so databases created by older versions are upgraded by these statements on every start,
+// each of them has to be idempotent
+var migrations = []string{
+	`ALTER TABLE AggregatedData ADD COLUMN IF NOT EXISTS Origin VARCHAR(10) NOT NULL DEFAULT 'live'`,
+	`ALTER TABLE LatestData ADD COLUMN IF NOT EXISTS Origin VARCHAR(10) NOT NULL DEFAULT 'live'`,
+	`ALTER TABLE AggregatedData ADD COLUMN IF NOT EXISTS Volume FLOAT NOT NULL DEFAULT 0`,
+	`ALTER TABLE AggregatedData ADD COLUMN IF NOT EXISTS Average_bid FLOAT NOT NULL DEFAULT 0`,
+	`ALTER TABLE AggregatedData ADD COLUMN IF NOT EXISTS Average_ask FLOAT NOT NULL DEFAULT 0`,
+	`ALTER TABLE LatestData ADD COLUMN IF NOT EXISTS Quantity FLOAT NOT NULL DEFAULT 0`,
+	`ALTER TABLE LatestData ADD COLUMN IF NOT EXISTS Bid FLOAT NOT NULL DEFAULT 0`,
+	`ALTER TABLE LatestData ADD COLUMN IF NOT EXISTS Ask FLOAT NOT NULL DEFAULT 0`,
+	`ALTER TABLE AggregatedData ADD COLUMN IF NOT EXISTS Price_sum FLOAT NOT NULL DEFAULT 0`,
+	`ALTER TABLE AggregatedData ADD COLUMN IF NOT EXISTS Tick_count BIGINT NOT NULL DEFAULT 0`,
+	`ALTER TABLE AggregatedData ADD COLUMN IF NOT EXISTS Weighted_sum FLOAT NOT NULL DEFAULT 0`,
+	`ALTER TABLE AggregatedData ADD COLUMN IF NOT EXISTS Weighted_time BIGINT NOT NULL DEFAULT 0`,
+	`ALTER TABLE AggregatedData ADD COLUMN IF NOT EXISTS Bid_sum FLOAT NOT NULL DEFAULT 0`,
+	`ALTER TABLE AggregatedData ADD COLUMN IF NOT EXISTS Ask_sum FLOAT NOT NULL DEFAULT 0`,
+	`ALTER TABLE AggregatedData ADD COLUMN IF NOT EXISTS Quote_count BIGINT NOT NULL DEFAULT 0`,
+
+	`CREATE TABLE IF NOT EXISTS Symbols(
+    Symbol VARCHAR(20) PRIMARY KEY,
+    Active BOOLEAN NOT NULL,
+    Discovered BOOLEAN NOT NULL DEFAULT FALSE,
+    UpdatedTime TimestampTZ NOT NULL DEFAULT NOW()
+)`,
+
+	`CREATE TABLE IF NOT EXISTS Candles(
+    Exchange VARCHAR(100) NOT NULL,
+    Pair_name VARCHAR NOT NULL,
+    Resolution VARCHAR(3) NOT NULL,
+    StartTime TimestampTZ NOT NULL,
+    Open_price FLOAT NOT NULL,
+    High_price FLOAT NOT NULL,
+    Low_price FLOAT NOT NULL,
+    Close_price FLOAT NOT NULL,
+    Ticks BIGINT NOT NULL,
+    Volume FLOAT NOT NULL DEFAULT 0,
+    Origin VARCHAR(10) NOT NULL DEFAULT 'live',
+    OpenTime BIGINT NOT NULL,
+    CloseTime BIGINT NOT NULL,
+    PRIMARY KEY (Exchange, Pair_name, Resolution, StartTime)
+)`,
+	`CREATE INDEX IF NOT EXISTS candles_resolution_start ON Candles (Resolution, StartTime)`,
+	`CREATE OR REPLACE FUNCTION expire_candles_delete_old_rows() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  DELETE FROM Candles WHERE Resolution = '1s' AND StartTime < NOW() - INTERVAL '1 day';
+  DELETE FROM Candles WHERE Resolution IN ('1m', '5m', '15m') AND StartTime < NOW() - INTERVAL '7 weeks';
+  RETURN NEW;
+END;
+$$`,
+	`CREATE OR REPLACE TRIGGER expire_candles_delete_old_rows_trigger
+    AFTER INSERT ON Candles
+    EXECUTE PROCEDURE expire_candles_delete_old_rows()`,
+}
+
+// Migrate applies the schema changes, the statements are run in order and stop at the first error
+func (repo *PostgresDatabase) Migrate() error {
+	for i, stmt := range migrations {
+		if _, err := repo.Db.Exec(stmt); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+
+	slog.Info("Database schema is up to date", "migrations", len(migrations))
+	return nil
+}
@@ -13,8 +13,8 @@ func (repo *PostgresDatabase) SaveAggregatedData(aggregatedData map[string]domai
 	}
 
 	stmt, err := tx.Prepare(`
-		INSERT INTO AggregatedData(Pair_name, Exchange, StoredTime, Average_price, Min_price, Max_price)
-		VALUES($1, $2, $3, $4, $5, $6)
+		INSERT INTO AggregatedData(Pair_name, Exchange, StoredTime, Average_price, Min_price, Max_price, Origin, Volume, Average_bid, Average_ask, Price_sum, Tick_count, Weighted_sum, Weighted_time, Bid_sum, Ask_sum, Quote_count)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 		`)
 	if err != nil {
 		tx.Rollback()
@@ -24,7 +24,7 @@ func (repo *PostgresDatabase) SaveAggregatedData(aggregatedData map[string]domai
 	defer stmt.Close()
 	fmt.Println(len(aggregatedData))
 	for _, data := range aggregatedData {
-		_, err := stmt.Exec(data.Pair_name, data.Exchange, data.Timestamp, data.Average_price, data.Min_price, data.Max_price)
+		_, err := stmt.Exec(data.Pair_name, data.Exchange, data.Timestamp, data.Average_price, data.Min_price, data.Max_price, originOrLive(data.Origin), data.Volume, data.Average_bid, data.Average_ask, data.Price_sum, data.Tick_count, data.Weighted_sum, data.Weighted_time, data.Bid_sum, data.Ask_sum, data.Quote_count)
 		if err != nil {
 			tx.Rollback()
 			slog.Error("Failed to execute statement", "pair", data.Pair_name, "exchange", data.Exchange, "error", err.Error())
@@ -42,11 +42,15 @@ func (repo *PostgresDatabase) SaveLatestData(latestData map[string]domain.Data)
 	}
 
 	stmt, err := tx.Prepare(`
-		INSERT INTO LatestData (Exchange, Pair_name, Price, StoredTime)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO LatestData (Exchange, Pair_name, Price, StoredTime, Origin, Quantity, Bid, Ask)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		ON CONFLICT (Exchange, Pair_name) DO UPDATE
 		SET Price = EXCLUDED.Price,
-    	StoredTime = EXCLUDED.StoredTime;
+    	StoredTime = EXCLUDED.StoredTime,
+    	Origin = EXCLUDED.Origin,
+    	Quantity = EXCLUDED.Quantity,
+    	Bid = EXCLUDED.Bid,
+    	Ask = EXCLUDED.Ask;
 		`)
 	if err != nil {
 		tx.Rollback()
@@ -55,7 +59,7 @@ func (repo *PostgresDatabase) SaveLatestData(latestData map[string]domain.Data)
 	defer stmt.Close()
 
 	for _, data := range latestData {
-		if _, err := stmt.Exec(data.ExchangeName, data.Symbol, data.Price, data.Timestamp); err != nil {
+		if _, err := stmt.Exec(data.ExchangeName, data.Symbol, data.Price, data.Timestamp, originOrLive(data.Origin), data.Quantity, data.Bid, data.Ask); err != nil {
 			tx.Rollback()
 			return err
 		}
@@ -63,3 +67,11 @@ func (repo *PostgresDatabase) SaveLatestData(latestData map[string]domain.Data)
 
 	return tx.Commit()
 }
+
+// Data without origin comes from live exchanges
+func originOrLive(origin string) string {
+	if origin == "" {
+		return domain.OriginLive
+	}
+	return origin
+}
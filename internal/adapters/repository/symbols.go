@@ -0,0 +1,39 @@
+package repository
+
+import "marketflow/internal/domain"
+
+var _ (domain.SymbolStore) = (*PostgresDatabase)(nil)
+
+// Loads symbols changed at runtime
+func (repo *PostgresDatabase) LoadSymbols() ([]domain.Symbol, error) {
+	rows, err := repo.Db.Query(`
+		SELECT Symbol, Active, Discovered, UpdatedTime
+		FROM Symbols
+		`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	symbols := make([]domain.Symbol, 0)
+	for rows.Next() {
+		var symbol domain.Symbol
+		if err := rows.Scan(&symbol.Name, &symbol.Active, &symbol.Discovered, &symbol.UpdatedAt); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, rows.Err()
+}
+
+func (repo *PostgresDatabase) SaveSymbol(symbol domain.Symbol) error {
+	_, err := repo.Db.Exec(`
+		INSERT INTO Symbols (Symbol, Active, Discovered, UpdatedTime)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (Symbol) DO UPDATE
+		SET Active = EXCLUDED.Active,
+		Discovered = EXCLUDED.Discovered,
+		UpdatedTime = EXCLUDED.UpdatedTime;
+		`, symbol.Name, symbol.Active, symbol.Discovered, symbol.UpdatedAt)
+	return err
+}
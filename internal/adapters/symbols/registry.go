@@ -0,0 +1,238 @@
+package symbols
+
+import (
+	"log/slog"
+	"marketflow/internal/domain"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var symbolName = regexp.MustCompile(`^[A-Z0-9]{2,20}$`)
+
+// Registry keeps the symbols in memory, changes are written to the store
+//
+// Readers never wait for the store: a change is saved first and then applied under the lock,
+// symbols discovered on the feeds are registered by a background goroutine
+type Registry struct {
+	store         domain.SymbolStore
+	autoDiscover  bool
+	discoverLimit int
+	discoverCh    chan string
+
+	writeMu sync.Mutex // keeps the store and memory changes in the same order
+
+	mu          sync.RWMutex
+	symbols     map[string]domain.Symbol
+	active      []string        // sorted names of active symbols
+	pending     map[string]bool // discovered symbols waiting to be saved
+	discovered  int             // discovered symbols including the pending ones
+	limitLogged bool
+}
+
+var _ domain.SymbolRegistry = (*Registry)(nil)
+
+// NewRegistry loads SYMBOLS (comma separated, default are the five known pairs), then the stored symbols
+// which override the config, SYMBOL_AUTO_DISCOVER=true adds unknown symbols seen on the feeds
+// up to SYMBOL_DISCOVER_LIMIT of them (default 20), store could be nil
+func NewRegistry(store domain.SymbolStore) *Registry {
+	r := &Registry{
+		store:         store,
+		autoDiscover:  os.Getenv("SYMBOL_AUTO_DISCOVER") == "true",
+		discoverLimit: 20,
+		symbols:       make(map[string]domain.Symbol),
+		pending:       make(map[string]bool),
+	}
+
+	if val := os.Getenv("SYMBOL_DISCOVER_LIMIT"); val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+			r.discoverLimit = n
+		} else {
+			slog.Warn("Invalid SYMBOL_DISCOVER_LIMIT value, using default", "value", val, "default", r.discoverLimit)
+		}
+	}
+
+	names := domain.DefaultSymbols
+	if val := os.Getenv("SYMBOLS"); val != "" {
+		names = strings.Split(val, ",")
+	}
+
+	now := time.Now()
+	for _, name := range names {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if !symbolName.MatchString(name) {
+			slog.Warn("Invalid symbol in SYMBOLS is skipped", "symbol", name)
+			continue
+		}
+		r.symbols[name] = domain.Symbol{Name: name, Active: true, UpdatedAt: now}
+	}
+
+	if store != nil {
+		stored, err := store.LoadSymbols()
+		if err != nil {
+			slog.Warn("Failed to load stored symbols, using config only", "error", err.Error())
+		}
+		for _, symbol := range stored {
+			r.symbols[symbol.Name] = symbol
+			if symbol.Discovered {
+				r.discovered++
+			}
+		}
+	}
+
+	r.refresh()
+
+	if r.autoDiscover {
+		// Every pending symbol fits, so Observe never waits for the channel
+		r.discoverCh = make(chan string, r.discoverLimit)
+		go r.register()
+	}
+	return r
+}
+
+func (r *Registry) List() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.active...)
+}
+
+func (r *Registry) Active(symbol string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.symbols[symbol].Active
+}
+
+func (r *Registry) Known(symbol string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.symbols[symbol]
+	return ok
+}
+
+// Observe queues unknown symbol for registration, its ticks are rejected until the symbol is saved
+func (r *Registry) Observe(symbol string) bool {
+	r.mu.RLock()
+	known, ok := r.symbols[symbol]
+	r.mu.RUnlock()
+
+	// Retired symbols are not discovered again
+	if ok || !r.autoDiscover || !symbolName.MatchString(symbol) {
+		return known.Active
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if known, ok := r.symbols[symbol]; ok || r.pending[symbol] {
+		return known.Active
+	}
+	if r.discovered >= r.discoverLimit {
+		if !r.limitLogged {
+			r.limitLogged = true
+			slog.Warn("Symbol discovery limit is reached, new feed symbols are rejected", "limit", r.discoverLimit, "symbol", symbol)
+		}
+		return false
+	}
+
+	r.pending[symbol] = true
+	r.discovered++
+	r.discoverCh <- symbol
+	return false
+}
+
+// Saves discovered symbols, failed ones could be discovered again
+func (r *Registry) register() {
+	for symbol := range r.discoverCh {
+		saved, err := r.put(domain.Symbol{Name: symbol, Active: true, Discovered: true})
+
+		r.mu.Lock()
+		delete(r.pending, symbol)
+		if err != nil || !saved.Discovered {
+			r.discovered--
+		}
+		r.mu.Unlock()
+
+		if err == nil && saved.Discovered {
+			slog.Info("New symbol is discovered on the feed", "symbol", symbol)
+		}
+	}
+}
+
+// All returns active and retired symbols in alphabetical order
+func (r *Registry) All() []domain.Symbol {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := make([]domain.Symbol, 0, len(r.symbols))
+	for _, symbol := range r.symbols {
+		all = append(all, symbol)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// Add registers the symbol or activates the retired one
+func (r *Registry) Add(symbol string) (domain.Symbol, error) {
+	if !symbolName.MatchString(symbol) {
+		return domain.Symbol{}, domain.ErrInvalidSymbolName
+	}
+	return r.put(domain.Symbol{Name: symbol, Active: true})
+}
+
+// Retire stops accepting ticks of the symbol, the symbol stays known
+func (r *Registry) Retire(symbol string) (domain.Symbol, error) {
+	r.mu.RLock()
+	known, ok := r.symbols[symbol]
+	r.mu.RUnlock()
+	if !ok {
+		return domain.Symbol{}, domain.ErrSymbolNotFound
+	}
+
+	known.Active = false
+	return r.put(known)
+}
+
+// Saves the symbol to the store first, so the registry doesn't have changes which are lost on restart,
+// readers are not blocked by the store round-trip
+func (r *Registry) put(symbol domain.Symbol) (domain.Symbol, error) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	// Another caller could change the symbol meanwhile, explicit changes win over discovery
+	r.mu.RLock()
+	current, ok := r.symbols[symbol.Name]
+	r.mu.RUnlock()
+	if ok && symbol.Discovered {
+		return current, nil
+	}
+
+	symbol.UpdatedAt = time.Now()
+	if r.store != nil {
+		if err := r.store.SaveSymbol(symbol); err != nil {
+			slog.Error("Failed to save symbol", "symbol", symbol.Name, "error", err.Error())
+			return domain.Symbol{}, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.symbols[symbol.Name] = symbol
+	r.refresh()
+	return symbol, nil
+}
+
+// Rebuilds the active list, r.mu must be locked or the registry not shared yet
+func (r *Registry) refresh() {
+	active := make([]string, 0, len(r.symbols))
+	for name, symbol := range r.symbols {
+		if symbol.Active {
+			active = append(active, name)
+		}
+	}
+	sort.Strings(active)
+	r.active = active
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"log/slog"
+	"marketflow/internal/api/senders"
+	"net/http"
+)
+
+// Handler for OHLC candles of the exchange or All
+//
+// Query parameters:
+//   - interval : 1s, 1m, 5m, 15m, 1h or 1d (default 1m)
+//   - from, to : RFC3339 time or unix milliseconds, the range is [from, to) (default last 100 intervals)
+func (h *MarketDataHTTPHandler) GetCandles(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+	query := r.URL.Query()
+
+	candles, code, err := h.serv.GetCandles(exchange, symbol, query.Get("interval"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		slog.Error("Failed to get candles", "exchange", exchange, "symbol", symbol, "error", err.Error())
+		senders.SendMsg(w, code, err.Error())
+		return
+	}
+
+	if err := senders.SendJSON(w, code, candles); err != nil {
+		slog.Error("Failed to send candles: " + err.Error())
+	}
+}
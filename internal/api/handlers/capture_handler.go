@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"log/slog"
+	"marketflow/internal/api/senders"
+	"net/http"
+)
+
+// Core handler for starting and stopping raw ticks capture
+func (h *SwitchModeHTTPHandler) SwitchCapture(w http.ResponseWriter, r *http.Request) {
+	action := r.PathValue("action")
+	status, code, err := h.serv.SwitchCapture(action)
+	if err != nil {
+		slog.Error("Failed to switch ticks capture", "action", action, "message", err.Error())
+		senders.SendMsg(w, code, err.Error())
+		return
+	}
+
+	if err := senders.SendJSON(w, http.StatusOK, status); err != nil {
+		slog.Error("Failed to send capture status: " + err.Error())
+		return
+	}
+	slog.Info("Ticks capture switched", "action", action)
+}
+
+// Handler for raw ticks capture state
+func (h *SwitchModeHTTPHandler) CaptureStatus(w http.ResponseWriter, r *http.Request) {
+	if err := senders.SendJSON(w, http.StatusOK, h.serv.CaptureStatus()); err != nil {
+		slog.Error("Failed to send capture status: " + err.Error())
+		senders.SendMsg(w, http.StatusInternalServerError, err.Error())
+	}
+}
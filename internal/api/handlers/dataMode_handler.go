@@ -17,8 +17,16 @@ func NewSwitchModeHandler(serv domain.DataModeService) *SwitchModeHTTPHandler {
 }
 
 // Core handler for switching datafetcher mode
+//
+// Query parameters of the test mode:
+//   - scenario : scenario file inside SCENARIO_DIR, running test mode is restarted with it
 func (h *SwitchModeHTTPHandler) SwitchMode(w http.ResponseWriter, r *http.Request) {
 	mode := r.PathValue("mode")
+	if scenario := r.URL.Query().Get("scenario"); mode == "test" && scenario != "" {
+		h.switchToScenario(w, scenario)
+		return
+	}
+
 	if code, err := h.serv.SwitchMode(mode); err != nil {
 		slog.Error("Failed to switch mode", "message", err.Error())
 		senders.SendMsg(w, code, err.Error())
@@ -30,3 +38,34 @@ func (h *SwitchModeHTTPHandler) SwitchMode(w http.ResponseWriter, r *http.Reques
 	senders.SendMsg(w, http.StatusOK, msg)
 	slog.Info(msg)
 }
+
+// Handler for switching datafetcher to the replay of recorded ticks
+//
+// Query parameters:
+//   - file : recorded NDJSON or CSV file inside REPLAY_DIR
+//   - speed : replay speed multiplier, "max" to replay as fast as possible (default 1)
+func (h *SwitchModeHTTPHandler) SwitchToReplay(w http.ResponseWriter, r *http.Request) {
+	file := r.URL.Query().Get("file")
+	speed := r.URL.Query().Get("speed")
+	if code, err := h.serv.SwitchToReplayMode(file, speed); err != nil {
+		slog.Error("Failed to switch to replay mode", "file", file, "speed", speed, "message", err.Error())
+		senders.SendMsg(w, code, err.Error())
+		return
+	}
+
+	msg := fmt.Sprintf("Datafetcher mode switched to replay of %s", file)
+	senders.SendMsg(w, http.StatusOK, msg)
+	slog.Info(msg)
+}
+
+func (h *SwitchModeHTTPHandler) switchToScenario(w http.ResponseWriter, scenario string) {
+	if code, err := h.serv.SwitchToTestScenario(scenario); err != nil {
+		slog.Error("Failed to switch to test scenario", "scenario", scenario, "message", err.Error())
+		senders.SendMsg(w, code, err.Error())
+		return
+	}
+
+	msg := fmt.Sprintf("Datafetcher mode switched to test with scenario %s", scenario)
+	senders.SendMsg(w, http.StatusOK, msg)
+	slog.Info(msg)
+}
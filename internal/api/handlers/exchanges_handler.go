@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"log/slog"
+	"marketflow/internal/api/senders"
+	"net/http"
+)
+
+// Handler for live exchanges connection reports
+func (h *SwitchModeHTTPHandler) ExchangeStatuses(w http.ResponseWriter, r *http.Request) {
+	res := h.serv.ExchangeStatuses()
+
+	if err := senders.SendJSON(w, http.StatusOK, res); err != nil {
+		slog.Error("Failed to send exchanges data: " + err.Error())
+		senders.SendMsg(w, http.StatusInternalServerError, err.Error())
+	}
+}
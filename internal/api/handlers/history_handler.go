@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"log/slog"
+	"marketflow/internal/api/senders"
+	"net/http"
+)
+
+// Handler for the stored price history of the exchange or All
+//
+// Query parameters:
+//   - from, to   : RFC3339 time or unix milliseconds, the range is [from, to) (default all stored rows)
+//   - limit      : page size from 1 to 1000 (default 100)
+//   - page_token : next_page_token of the previous page, from and to must stay the same
+func (h *MarketDataHTTPHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	exchange := r.PathValue("exchange")
+	symbol := r.PathValue("symbol")
+	query := r.URL.Query()
+
+	history, code, err := h.serv.GetPriceHistory(exchange, symbol, query.Get("from"), query.Get("to"), query.Get("page_token"), query.Get("limit"))
+	if err != nil {
+		slog.Error("Failed to get price history", "exchange", exchange, "symbol", symbol, "error", err.Error())
+		senders.SendMsg(w, code, err.Error())
+		return
+	}
+
+	if err := senders.SendJSON(w, code, history); err != nil {
+		slog.Error("Failed to send price history: " + err.Error())
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"marketflow/internal/api/senders"
+	"marketflow/internal/domain"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Limit of the POST /ingest body size
+const maxIngestBody = 8 << 20
+
+type ingestSourceKey struct{}
+
+// Handler for ticks pushed by external producers
+//
+// The body is a JSON array of ticks, a single tick or NDJSON (one tick per line),
+// ticks have the fields of the exchange messages: symbol, price, timestamp (unix ms, optional),
+// quantity, bid and ask (optional). The exchange of the ticks is the authenticated source
+func (h *SwitchModeHTTPHandler) IngestTicks(w http.ResponseWriter, r *http.Request) {
+	source, _ := r.Context().Value(ingestSourceKey{}).(string)
+
+	ticks, err := decodeTicks(http.MaxBytesReader(w, r.Body, maxIngestBody))
+	if err != nil {
+		code := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		slog.Warn("Failed to decode ingest batch", "source", source, "message", err.Error())
+		senders.SendMsg(w, code, "invalid ingest batch: "+err.Error())
+		return
+	}
+
+	accepted, code, err := h.serv.IngestTicks(source, ticks)
+	if err != nil {
+		slog.Warn("Ingest batch is rejected", "source", source, "ticks", len(ticks), "message", err.Error())
+		if code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", "1")
+		}
+		senders.SendMsg(w, code, err.Error())
+		return
+	}
+
+	slog.Debug("Ingest batch is accepted", "source", source, "ticks", accepted)
+	senders.SendMsg(w, code, fmt.Sprintf("%d ticks accepted", accepted))
+}
+
+// Decodes JSON array, single JSON tick or NDJSON ticks
+func decodeTicks(r io.Reader) ([]domain.Data, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			break
+		}
+		br.ReadByte()
+	}
+
+	dec := json.NewDecoder(br)
+	ticks := make([]domain.Data, 0)
+	if b, _ := br.Peek(1); b[0] == '[' {
+		if err := dec.Decode(&ticks); err != nil {
+			return nil, err
+		}
+		return ticks, nil
+	}
+
+	// Concatenated objects are NDJSON lines
+	for line := 1; ; line++ {
+		tick := domain.Data{}
+		if err := dec.Decode(&tick); err != nil {
+			if err == io.EOF {
+				return ticks, nil
+			}
+			return nil, fmt.Errorf("tick %d: %w", line, err)
+		}
+		ticks = append(ticks, tick)
+	}
+}
+
+// IngestSources returns producer names of INGEST_TOKENS ("source:token,..."), they are valid exchange names,
+// sources named as one of the exchanges or "All" are rejected
+func IngestSources(exchanges []string) []string {
+	sources := make([]string, 0)
+	for source := range ingestTokens(exchanges) {
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// Parses INGEST_TOKENS into tokens by source, pushed ticks can't be mixed into the feed of an exchange
+// or take the name of the all exchanges aggregates
+func ingestTokens(exchanges []string) map[string]string {
+	tokens := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv("INGEST_TOKENS"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		source, token, ok := strings.Cut(pair, ":")
+		source, token = strings.TrimSpace(source), strings.TrimSpace(token)
+		if !ok || source == "" || token == "" {
+			slog.Warn("Invalid ingest token, skipping", "source", source)
+			continue
+		}
+		if source == "All" || slices.Contains(exchanges, source) {
+			slog.Warn("Ingest source has the name of an exchange, skipping", "source", source)
+			continue
+		}
+		tokens[source] = token
+	}
+	return tokens
+}
+
+// IngestAuth requires "Authorization: Bearer {token}" header with a token of INGEST_TOKENS,
+// the source of the token is passed to the handler. Without INGEST_TOKENS all requests are rejected,
+// tokens of sources named as the exchanges are not accepted
+func IngestAuth(exchanges []string, next http.HandlerFunc) http.HandlerFunc {
+	tokens := ingestTokens(exchanges)
+	if len(tokens) == 0 {
+		slog.Warn("INGEST_TOKENS is not set, push ingest is disabled")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			for source, token := range tokens {
+				if subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+					next(w, r.WithContext(context.WithValue(r.Context(), ingestSourceKey{}, source)))
+					return
+				}
+			}
+		}
+		senders.SendMsg(w, http.StatusUnauthorized, domain.ErrIngestUnauthorized.Error())
+	}
+}
@@ -10,10 +10,11 @@ import (
 
 type MarketDataHTTPHandler struct {
 	serv domain.DataModeService
+	ws   WSConfig
 }
 
 func NewMarketDataHandler(serv domain.DataModeService) *MarketDataHTTPHandler {
-	return &MarketDataHTTPHandler{serv: serv}
+	return &MarketDataHTTPHandler{serv: serv, ws: LoadWSConfig()}
 }
 
 const (
@@ -24,6 +25,10 @@ const (
 )
 
 // Core handler for processing metric-based queries by specific exchange
+//
+// Highest, lowest and average accept either period (relative to now) or from and to (RFC3339 or unix ms,
+// the range is [from, to)), average also accepts weighting=tick (default, every tick has the same weight)
+// or weighting=time
 func (h *MarketDataHTTPHandler) ProcessMetricQueryByExchange(w http.ResponseWriter, r *http.Request) {
 	var (
 		data domain.Data
@@ -53,10 +58,26 @@ func (h *MarketDataHTTPHandler) ProcessMetricQueryByExchange(w http.ResponseWrit
 		return
 	}
 
+	// Absolute time range, period is relative to now so they can't be combined
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	ranged := from != "" || to != ""
+	if ranged && r.URL.Query().Get("period") != "" {
+		slog.Error("Failed to get data by metric: ", "exchange", exchange, "symbol", symbol, "error", domain.ErrPeriodWithTimeRange.Error())
+		senders.SendMsg(w, http.StatusBadRequest, domain.ErrPeriodWithTimeRange.Error())
+		return
+	}
+
 	switch metric {
 	case MetricHighest:
 		period := r.URL.Query().Get("period")
-		if period == "" {
+		if ranged {
+			data, code, err = h.serv.GetHighestPriceInRange(exchange, symbol, from, to)
+			if err != nil {
+				slog.Error("Failed to get highest price by time range: ", "exchange", exchange, "symbol", symbol, "from", from, "to", to, "error", err.Error())
+				senders.SendMsg(w, code, err.Error())
+				return
+			}
+		} else if period == "" {
 			data, code, err = h.serv.GetHighestPrice(exchange, symbol)
 			if err != nil {
 				slog.Error("Failed to get highest price: ", "exchange", exchange, "symbol", symbol, "error", err.Error())
@@ -76,7 +97,14 @@ func (h *MarketDataHTTPHandler) ProcessMetricQueryByExchange(w http.ResponseWrit
 	case MetricLowest:
 		period := r.URL.Query().Get("period")
 
-		if period == "" {
+		if ranged {
+			data, code, err = h.serv.GetLowestPriceInRange(exchange, symbol, from, to)
+			if err != nil {
+				slog.Error("Failed to get lowest price by time range: ", "exchange", exchange, "symbol", symbol, "from", from, "to", to, "error", err.Error())
+				senders.SendMsg(w, code, err.Error())
+				return
+			}
+		} else if period == "" {
 			data, code, err = h.serv.GetLowestPrice(exchange, symbol)
 			if err != nil {
 				slog.Error("Failed to get lowest price: ", "exchange", exchange, "symbol", symbol, "error", err.Error())
@@ -94,8 +122,16 @@ func (h *MarketDataHTTPHandler) ProcessMetricQueryByExchange(w http.ResponseWrit
 		msg = fmt.Sprintf("Lowest price for %s at %s duration {%s}: %.2f", symbol, exchange, period, data.Price)
 	case MetricAverage:
 		period := r.URL.Query().Get("period")
-		if period == "" {
-			data, code, err = h.serv.GetAveragePrice(exchange, symbol)
+		weighting := r.URL.Query().Get("weighting")
+		if ranged {
+			data, code, err = h.serv.GetAveragePriceInRange(exchange, symbol, from, to, weighting)
+			if err != nil {
+				slog.Error("Failed to get average price by time range: ", "exchange", exchange, "symbol", symbol, "from", from, "to", to, "error", err.Error())
+				senders.SendMsg(w, code, err.Error())
+				return
+			}
+		} else if period == "" {
+			data, code, err = h.serv.GetAveragePrice(exchange, symbol, weighting)
 			if err != nil {
 				slog.Error("Failed to get average price: ", "exchange", exchange, "symbol", symbol, "error", err.Error())
 				senders.SendMsg(w, code, err.Error())
@@ -103,7 +139,7 @@ func (h *MarketDataHTTPHandler) ProcessMetricQueryByExchange(w http.ResponseWrit
 			}
 
 		} else {
-			data, code, err = h.serv.GetAveragePriceWithPeriod(exchange, symbol, period)
+			data, code, err = h.serv.GetAveragePriceWithPeriod(exchange, symbol, period, weighting)
 			if err != nil {
 				slog.Error("Failed to get average price with period: ", "exchange", exchange, "symbol", symbol, "period", period, "error", err.Error())
 				senders.SendMsg(w, code, err.Error())
@@ -136,7 +172,7 @@ func (h *MarketDataHTTPHandler) ProcessMetricQueryByExchange(w http.ResponseWrit
 	slog.Info(msg)
 }
 
-// Core handler for processing metric-based queries across all exchanges
+// Core handler for processing metric-based queries across all exchanges, query parameters are the same as by exchange
 func (h *MarketDataHTTPHandler) ProcessMetricQueryByAll(w http.ResponseWriter, r *http.Request) {
 	var (
 		data     domain.Data
@@ -159,10 +195,26 @@ func (h *MarketDataHTTPHandler) ProcessMetricQueryByAll(w http.ResponseWriter, r
 		return
 	}
 
+	// Absolute time range, period is relative to now so they can't be combined
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	ranged := from != "" || to != ""
+	if ranged && r.URL.Query().Get("period") != "" {
+		slog.Error("Failed to get data by metric: ", "exchange", exchange, "symbol", symbol, "error", domain.ErrPeriodWithTimeRange.Error())
+		senders.SendMsg(w, http.StatusBadRequest, domain.ErrPeriodWithTimeRange.Error())
+		return
+	}
+
 	switch metric {
 	case MetricHighest:
 		period := r.URL.Query().Get("period")
-		if period == "" {
+		if ranged {
+			data, code, err = h.serv.GetHighestPriceInRange(exchange, symbol, from, to)
+			if err != nil {
+				slog.Error("Failed to get highest price by time range: ", "exchange", exchange, "symbol", symbol, "from", from, "to", to, "error", err.Error())
+				senders.SendMsg(w, code, err.Error())
+				return
+			}
+		} else if period == "" {
 			data, code, err = h.serv.GetHighestPrice(exchange, symbol)
 			if err != nil {
 				slog.Error("Failed to get highest price: ", "exchange", exchange, "symbol", symbol, "error", err.Error())
@@ -180,7 +232,14 @@ func (h *MarketDataHTTPHandler) ProcessMetricQueryByAll(w http.ResponseWriter, r
 		msg = fmt.Sprintf("Highest price for %s at %s: %.2f", symbol, exchange, data.Price)
 	case MetricLowest:
 		period := r.URL.Query().Get("period")
-		if period == "" {
+		if ranged {
+			data, code, err = h.serv.GetLowestPriceInRange(exchange, symbol, from, to)
+			if err != nil {
+				slog.Error("Failed to get lowest price by time range: ", "exchange", exchange, "symbol", symbol, "from", from, "to", to, "error", err.Error())
+				senders.SendMsg(w, code, err.Error())
+				return
+			}
+		} else if period == "" {
 			data, code, err = h.serv.GetLowestPrice(exchange, symbol)
 			if err != nil {
 				slog.Error("Failed to get lowest price: ", "exchange", exchange, "symbol", symbol, "error", err.Error())
@@ -198,11 +257,29 @@ func (h *MarketDataHTTPHandler) ProcessMetricQueryByAll(w http.ResponseWriter, r
 
 		msg = fmt.Sprintf("Lowest price for %s at %s: %.2f", symbol, exchange, data.Price)
 	case MetricAverage:
-		data, code, err = h.serv.GetAveragePrice(exchange, symbol)
-		if err != nil {
-			slog.Error("Failed to get average price: ", "exchange", exchange, "symbol", symbol, "error", err.Error())
-			senders.SendMsg(w, code, err.Error())
-			return
+		period := r.URL.Query().Get("period")
+		weighting := r.URL.Query().Get("weighting")
+		if ranged {
+			data, code, err = h.serv.GetAveragePriceInRange(exchange, symbol, from, to, weighting)
+			if err != nil {
+				slog.Error("Failed to get average price by time range: ", "exchange", exchange, "symbol", symbol, "from", from, "to", to, "error", err.Error())
+				senders.SendMsg(w, code, err.Error())
+				return
+			}
+		} else if period == "" {
+			data, code, err = h.serv.GetAveragePrice(exchange, symbol, weighting)
+			if err != nil {
+				slog.Error("Failed to get average price: ", "exchange", exchange, "symbol", symbol, "error", err.Error())
+				senders.SendMsg(w, code, err.Error())
+				return
+			}
+		} else {
+			data, code, err = h.serv.GetAveragePriceByAllExchangesWithPeriod(symbol, period, weighting)
+			if err != nil {
+				slog.Error("Failed to get average price with period: ", "exchange", exchange, "symbol", symbol, "period", period, "error", err.Error())
+				senders.SendMsg(w, code, err.Error())
+				return
+			}
 		}
 
 		msg = fmt.Sprintf("Average price for %s at %s: %.2f", symbol, exchange, data.Price)
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"log/slog"
+	"marketflow/internal/api/senders"
+	"net/http"
+	"strconv"
+)
+
+// Handler for ticks removed from the ingest pipeline
+//
+// Query parameters:
+//   - reason : filter by removal reason (all reasons by default)
+//   - limit : number of the newest ticks (default 100, 0 returns all kept ticks)
+func (h *SwitchModeHTTPHandler) QuarantineReport(w http.ResponseWriter, r *http.Request) {
+	reason := r.URL.Query().Get("reason")
+
+	limit := 100
+	if val := r.URL.Query().Get("limit"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			slog.Error("Failed to parse quarantine limit", "limit", val)
+			senders.SendMsg(w, http.StatusBadRequest, "limit value is invalid, must be a non-negative number")
+			return
+		}
+		limit = n
+	}
+
+	if err := senders.SendJSON(w, http.StatusOK, h.serv.QuarantineReport(reason, limit)); err != nil {
+		slog.Error("Failed to send quarantine report: " + err.Error())
+		senders.SendMsg(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"marketflow/internal/api/senders"
+	"marketflow/internal/domain"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Comment line sent to idle streams, so proxies do not close the connection
+const streamHeartbeat = 15 * time.Second
+
+// Server-Sent Events stream of live prices
+//
+// Query parameters (comma separated, empty matches everything):
+//   - symbols   : e.g. BTCUSDT,ETHUSDT
+//   - exchanges : configured exchanges or All, ticks come from the exchanges and aggregates also from All
+//   - events    : tick (every accepted tick), aggregate (closed aggregation window) or candle (candle changed by a tick)
+//   - throttle  : e.g. 500ms, at most one event per event type (and candle interval), exchange and symbol is sent
+//     in the period, the latest one
+func (h *MarketDataHTTPHandler) StreamPrices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var throttle time.Duration
+	if val := query.Get("throttle"); val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			slog.Error("Failed to parse stream throttle", "throttle", val)
+			senders.SendMsg(w, http.StatusBadRequest, domain.ErrInvalidThrottleVal.Error())
+			return
+		}
+		throttle = d
+	}
+
+	filter := domain.StreamFilter{
+		Symbols:   splitList(query.Get("symbols")),
+		Exchanges: splitList(query.Get("exchanges")),
+		Events:    splitList(query.Get("events")),
+	}
+	sub, code, err := h.serv.SubscribePrices(filter)
+	if err != nil {
+		slog.Error("Failed to subscribe to prices", "error", err.Error())
+		senders.SendMsg(w, code, err.Error())
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx must not buffer the stream
+	w.WriteHeader(http.StatusOK)
+	if err := http.NewResponseController(w).Flush(); err != nil {
+		slog.Error("Failed to start price stream", "error", err.Error())
+		return
+	}
+	slog.Info("Price stream is opened", "client", r.RemoteAddr, "symbols", filter.Symbols, "exchanges", filter.Exchanges, "events", filter.Events, "throttle", throttle)
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	// Without throttle the flush channel stays nil and every event is sent at once
+	var (
+		flush   <-chan time.Time
+		pending = make(map[string]domain.PriceEvent)
+		order   []string
+	)
+	if throttle > 0 {
+		t := time.NewTicker(throttle)
+		defer t.Stop()
+		flush = t.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			slog.Info("Price stream is closed by client", "client", r.RemoteAddr, "dropped", sub.Dropped())
+			return
+		case ev, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if throttle == 0 {
+				if err := senders.SendEvent(w, ev.Type, ev); err != nil {
+					return
+				}
+				continue
+			}
+
+			key := ev.Type + " " + ev.Interval + " " + ev.Exchange + " " + ev.Symbol
+			if _, exist := pending[key]; !exist {
+				order = append(order, key)
+			}
+			pending[key] = ev
+		case <-flush:
+			for _, key := range order {
+				if err := senders.SendEvent(w, pending[key].Type, pending[key]); err != nil {
+					return
+				}
+			}
+			clear(pending)
+			order = order[:0]
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Splits comma separated query value, empty value is an empty list
+func splitList(val string) []string {
+	if val == "" {
+		return nil
+	}
+	list := strings.Split(val, ",")
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+	return list
+}
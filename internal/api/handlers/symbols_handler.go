@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"marketflow/internal/api/senders"
+	"marketflow/internal/domain"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Handler for the symbol registry
+func (h *SwitchModeHTTPHandler) ListSymbols(w http.ResponseWriter, r *http.Request) {
+	if err := senders.SendJSON(w, http.StatusOK, h.serv.ListSymbols()); err != nil {
+		slog.Error("Failed to send symbols: " + err.Error())
+		senders.SendMsg(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// Admin handler for adding a symbol or activating the retired one
+func (h *SwitchModeHTTPHandler) AddSymbol(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+	added, code, err := h.serv.AddSymbol(symbol)
+	if err != nil {
+		slog.Error("Failed to add symbol", "symbol", symbol, "message", err.Error())
+		senders.SendMsg(w, code, err.Error())
+		return
+	}
+
+	if err := senders.SendJSON(w, http.StatusOK, added); err != nil {
+		slog.Error("Failed to send symbol: " + err.Error())
+		return
+	}
+	slog.Info("Symbol is added", "symbol", added.Name)
+}
+
+// Admin handler for retiring a symbol
+func (h *SwitchModeHTTPHandler) RetireSymbol(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+	retired, code, err := h.serv.RetireSymbol(symbol)
+	if err != nil {
+		slog.Error("Failed to retire symbol", "symbol", symbol, "message", err.Error())
+		senders.SendMsg(w, code, err.Error())
+		return
+	}
+
+	if err := senders.SendJSON(w, http.StatusOK, retired); err != nil {
+		slog.Error("Failed to send symbol: " + err.Error())
+		return
+	}
+	slog.Info("Symbol is retired", "symbol", retired.Name)
+}
+
+// AdminOnly requires "Authorization: Bearer {ADMIN_TOKEN}" header, requests are not checked if ADMIN_TOKEN is empty
+func AdminOnly(next http.HandlerFunc) http.HandlerFunc {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		slog.Warn("ADMIN_TOKEN is not set, admin API is not protected")
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			senders.SendMsg(w, http.StatusUnauthorized, domain.ErrUnauthorized.Error())
+			return
+		}
+		next(w, r)
+	}
+}
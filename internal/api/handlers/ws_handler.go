@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"marketflow/internal/domain"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsPingInterval = 30 * time.Second
+	wsPongWait     = 2 * wsPingInterval // the client is gone without pong for this long
+	wsMaxMessage   = 64 << 10           // client requests are small
+	wsRequestQueue = 16                 // replies waiting for the writer, more means the client floods requests
+)
+
+// Limits of the WebSocket API clients
+type WSConfig struct {
+	Buffer       int           // events waiting for one client
+	SlowClient   string        // drop or disconnect, what happens to a client which buffer is full
+	WriteTimeout time.Duration // client which doesn't read a message for this long is disconnected
+}
+
+// LoadWSConfig reads WS_CLIENT_BUFFER (default 256), WS_SLOW_CLIENT_POLICY (default disconnect) and WS_WRITE_TIMEOUT (default 5s)
+func LoadWSConfig() WSConfig {
+	cfg := WSConfig{Buffer: 256, SlowClient: domain.SlowClientDisconnect, WriteTimeout: 5 * time.Second}
+
+	if val := os.Getenv("WS_CLIENT_BUFFER"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			slog.Warn("Invalid WS_CLIENT_BUFFER, using default", "value", val, "default", cfg.Buffer)
+		} else {
+			cfg.Buffer = n
+		}
+	}
+
+	switch val := os.Getenv("WS_SLOW_CLIENT_POLICY"); val {
+	case "":
+	case domain.SlowClientDrop, domain.SlowClientDisconnect:
+		cfg.SlowClient = val
+	default:
+		slog.Warn("Invalid WS_SLOW_CLIENT_POLICY, using default", "value", val, "default", cfg.SlowClient)
+	}
+
+	if val := os.Getenv("WS_WRITE_TIMEOUT"); val != "" {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			slog.Warn("Invalid WS_WRITE_TIMEOUT, using default", "value", val, "default", cfg.WriteTimeout)
+		} else {
+			cfg.WriteTimeout = d
+		}
+	}
+	return cfg
+}
+
+// Dashboards are served from other origins, the API is read only
+var upgrader = websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+
+// Request of the client: {"op": "subscribe" | "unsubscribe" | "list", "channels": [...]}
+type wsRequest struct {
+	Op       string   `json:"op"`
+	Channels []string `json:"channels"`
+}
+
+// Message to the client, update carries the channel and its data, the other types answer the requests
+type wsMessage struct {
+	Type     string   `json:"type"` // update, subscribed, unsubscribed, channels or error
+	Channel  string   `json:"channel,omitempty"`
+	Channels []string `json:"channels,omitempty"`
+	Data     any      `json:"data,omitempty"`
+	Message  string   `json:"message,omitempty"`
+}
+
+var errClientFlood = errors.New("too many requests waiting for replies")
+
+// WebSocket API of live prices, channels are changed at runtime by the client requests
+//
+// Channels:
+//   - latest:{exchange}:{symbol}             : every accepted tick, All gets ticks of every exchange
+//   - aggregate:{exchange}:{symbol}          : closed aggregation windows
+//   - candles:{interval}:{exchange}:{symbol} : candle changed by a tick
+//
+// Events wait for the client in a bounded buffer, a client which doesn't keep up loses events or
+// is disconnected with 1008 close code, see LoadWSConfig
+func (h *MarketDataHTTPHandler) StreamWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		slog.Error("Failed to upgrade WebSocket connection", "error", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	sub := h.serv.SubscribeChannels(h.ws.Buffer, h.ws.SlowClient)
+	defer sub.Close()
+	slog.Info("WebSocket client connected", "client", r.RemoteAddr)
+
+	replies := make(chan wsMessage, wsRequestQueue)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- readRequests(conn, sub, replies)
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	write := func(msg wsMessage) error {
+		conn.SetWriteDeadline(time.Now().Add(h.ws.WriteTimeout))
+		return conn.WriteJSON(msg)
+	}
+	closeWith := func(code int, reason string) {
+		msg := websocket.FormatCloseMessage(code, reason)
+		conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	}
+
+	for {
+		select {
+		case err := <-readErr:
+			if errors.Is(err, errClientFlood) {
+				closeWith(websocket.ClosePolicyViolation, err.Error())
+			}
+			slog.Info("WebSocket client disconnected", "client", r.RemoteAddr, "dropped", sub.Dropped())
+			return
+		case msg := <-replies:
+			if err := write(msg); err != nil {
+				slog.Warn("Failed to write to WebSocket client", "client", r.RemoteAddr, "error", err.Error())
+				return
+			}
+		case ev, ok := <-sub.Events():
+			if !ok {
+				if sub.Overflowed() {
+					slog.Warn("Slow WebSocket client is disconnected", "client", r.RemoteAddr, "buffer", h.ws.Buffer)
+					closeWith(websocket.ClosePolicyViolation, "slow consumer, event buffer is full")
+				} else {
+					closeWith(websocket.CloseGoingAway, "server is shutting down")
+				}
+				return
+			}
+			// A tick belongs to its exchange and All channels, the client gets an update per subscribed channel
+			for _, channel := range ev.Channels() {
+				if !sub.Has(channel) {
+					continue
+				}
+				if err := write(wsMessage{Type: "update", Channel: channel, Data: ev.Payload()}); err != nil {
+					slog.Warn("Failed to write to WebSocket client", "client", r.RemoteAddr, "error", err.Error())
+					return
+				}
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.ws.WriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Handles the client requests until the connection is closed, replies are written by the caller
+func readRequests(conn *websocket.Conn, sub domain.ChannelSubscription, replies chan<- wsMessage) error {
+	conn.SetReadLimit(wsMaxMessage)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var req wsRequest
+		if json.Unmarshal(raw, &req) != nil {
+			req = wsRequest{} // answered with error
+		}
+
+		var reply wsMessage
+		switch req.Op {
+		case "subscribe":
+			if err := sub.Subscribe(req.Channels); err != nil {
+				reply = wsMessage{Type: "error", Message: err.Error()}
+			} else {
+				reply = wsMessage{Type: "subscribed", Channels: req.Channels}
+			}
+		case "unsubscribe":
+			sub.Unsubscribe(req.Channels)
+			reply = wsMessage{Type: "unsubscribed", Channels: req.Channels}
+		case "list":
+			reply = wsMessage{Type: "channels", Channels: sub.Channels()}
+		default:
+			reply = wsMessage{Type: "error", Message: "request is invalid, must be JSON with op (subscribe, unsubscribe or list) and channels"}
+		}
+
+		select {
+		case replies <- reply:
+		default:
+			return errClientFlood
+		}
+	}
+}
@@ -2,6 +2,7 @@ package senders
 
 import (
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"marketflow/internal/domain"
 	"net/http"
@@ -42,12 +43,20 @@ func SendMetricData(w http.ResponseWriter, code int, rawdata domain.Data) error
 		Symbol       string  `json:"symbol"`
 		Price        float64 `json:"price"`
 		Timestamp    string  `json:"timestamp"` // Readable time :)
+		Origin       string  `json:"origin,omitempty"`
+		Quantity     float64 `json:"quantity,omitempty"`
+		Bid          float64 `json:"bid,omitempty"`
+		Ask          float64 `json:"ask,omitempty"`
 	}{
 		ExchangeName: rawdata.ExchangeName,
 		Symbol:       rawdata.Symbol,
 		Price:        rawdata.Price,
 		Timestamp: time.Unix(0, rawdata.Timestamp*int64(time.Millisecond)).
 			Format("2006-01-02 15:04:05"),
+		Origin:   rawdata.Origin,
+		Quantity: rawdata.Quantity,
+		Bid:      rawdata.Bid,
+		Ask:      rawdata.Ask,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -57,3 +66,15 @@ func SendMetricData(w http.ResponseWriter, code int, rawdata domain.Data) error
 	}
 	return nil
 }
+
+// Writes one Server-Sent Event with JSON data and flushes it to the client
+func SendEvent(w http.ResponseWriter, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	return http.NewResponseController(w).Flush()
+}
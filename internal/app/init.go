@@ -4,22 +4,34 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	datafetcher "marketflow/internal/adapters/dataFetcher"
 	"marketflow/internal/api/handlers"
 	"marketflow/internal/domain"
 	"marketflow/internal/packages/envzilla"
 	"marketflow/internal/service"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"time"
 )
 
+// Names of the configured exchanges, push ingest sources can't use them
+var exchangeNames []string
+
 func init() {
 	checkFlags()
 
 	if err := envzilla.Loader(".env"); err != nil {
 		log.Fatalf("Config file load error: %s", err.Error())
 	}
+
+	// Exchange names validation list is built from the exchanges config and the push ingest sources
+	exchangeNames = datafetcher.ExchangeNames(datafetcher.LoadExchangeConfigs())
+	if len(exchangeNames) == 0 {
+		exchangeNames = slices.Clone(domain.Exchanges[:len(domain.Exchanges)-1])
+	}
+	domain.SetExchanges(append(slices.Clone(exchangeNames), handlers.IngestSources(exchangeNames)...))
 }
 
 // Setup function sets connection to the adapters
@@ -29,12 +41,30 @@ func Setup(db domain.Database, cacheMemory domain.CacheMemory, datafetchServ *se
 
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("POST /mode/{mode}", modeHandler.SwitchMode) // Switch to MODE
+	mux.HandleFunc("POST /mode/{mode}", modeHandler.SwitchMode)     // Switch to MODE
+	mux.HandleFunc("POST /mode/replay", modeHandler.SwitchToReplay) // Replay recorded ticks file
+
+	mux.HandleFunc("POST /capture/{action}", modeHandler.SwitchCapture) // Start or stop raw ticks capture
+	mux.HandleFunc("GET /capture", modeHandler.CaptureStatus)           // Returns capture state
 
-	mux.HandleFunc("GET /health", modeHandler.CheckHealth) // Returns system status
+	mux.HandleFunc("GET /ticks/quarantine", modeHandler.QuarantineReport) // Returns ticks removed from the ingest pipeline
 
+	mux.HandleFunc("GET /symbols", modeHandler.ListSymbols)                                  // Returns the symbol registry
+	mux.HandleFunc("POST /symbols/{symbol}", handlers.AdminOnly(modeHandler.AddSymbol))      // Adds or activates symbol
+	mux.HandleFunc("DELETE /symbols/{symbol}", handlers.AdminOnly(modeHandler.RetireSymbol)) // Retires symbol
+
+	mux.HandleFunc("POST /ingest", handlers.IngestAuth(exchangeNames, modeHandler.IngestTicks)) // Accepts ticks pushed by external producers
+
+	mux.HandleFunc("GET /health", modeHandler.CheckHealth)         // Returns system status
+	mux.HandleFunc("GET /exchanges", modeHandler.ExchangeStatuses) // Returns live exchanges connection states
+
+	mux.HandleFunc("GET /prices/history/{exchange}/{symbol}", marketHandler.GetPriceHistory) // Stored aggregated rows by pages, exchange could be All
 	mux.HandleFunc("GET /prices/{metric}/{symbol}", marketHandler.ProcessMetricQueryByAll)
 	mux.HandleFunc("GET /prices/{metric}/{exchange}/{symbol}", marketHandler.ProcessMetricQueryByExchange)
+
+	mux.HandleFunc("GET /candles/{exchange}/{symbol}", marketHandler.GetCandles) // OHLC candles, exchange could be All
+	mux.HandleFunc("GET /stream/prices", marketHandler.StreamPrices)             // Server-Sent Events of live ticks, aggregates and candles
+	mux.HandleFunc("GET /stream/ws", marketHandler.StreamWebSocket)              // WebSocket API with subscribe and unsubscribe per channel
 	fmt.Println(time.Now())
 	return mux
 }
@@ -0,0 +1,54 @@
+package domain
+
+// Weightings of the average price
+const (
+	WeightingTick = "tick" // every tick has the same weight
+	WeightingTime = "time" // every price is weighted by the time it was held
+)
+
+// PriceAverage keeps components of the average price, so averages of windows, stored rows
+// and the buffer could be combined without averaging averages
+type PriceAverage struct {
+	Sum          float64 // sum of tick prices
+	Count        int64   // ticks
+	WeightedSum  float64 // sum of prices multiplied by the time they were held, ms
+	WeightedTime int64   // time the prices were held, ms
+	Origin       string
+}
+
+// Add combines components of both averages
+func (a *PriceAverage) Add(b PriceAverage) {
+	a.Sum += b.Sum
+	a.Count += b.Count
+	a.WeightedSum += b.WeightedSum
+	a.WeightedTime += b.WeightedTime
+	if b.Count != 0 {
+		a.Origin = MergeOrigin(a.Origin, b.Origin)
+	}
+}
+
+// Value returns the average price of the weighting, false means there are no ticks
+func (a PriceAverage) Value(weighting string) (float64, bool) {
+	if weighting == WeightingTime {
+		if a.WeightedTime == 0 {
+			return 0, false
+		}
+		return a.WeightedSum / float64(a.WeightedTime), true
+	}
+
+	if a.Count == 0 {
+		return 0, false
+	}
+	return a.Sum / float64(a.Count), true
+}
+
+// CheckWeighting validates the weighting query value, empty value is tick weighting
+func CheckWeighting(weighting string) (string, error) {
+	switch weighting {
+	case "":
+		return WeightingTick, nil
+	case WeightingTick, WeightingTime:
+		return weighting, nil
+	}
+	return "", ErrInvalidWeightingVal
+}
@@ -0,0 +1,82 @@
+package domain
+
+import "time"
+
+// Candle resolutions, bucket boundaries are aligned to the unix epoch (1d candles start at UTC midnight)
+var CandleIntervals = []string{"1s", "1m", "5m", "15m", "1h", "1d"}
+
+var candleDurations = map[string]time.Duration{
+	"1s":  time.Second,
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// CandleDuration returns length of the candle interval
+func CandleDuration(interval string) (time.Duration, bool) {
+	d, ok := candleDurations[interval]
+	return d, ok
+}
+
+// OHLC candle of one exchange or All, open and close are the ticks with the earliest and latest event time
+type Candle struct {
+	Exchange string    `json:"exchange"`
+	Symbol   string    `json:"symbol"`
+	Interval string    `json:"interval"`
+	Start    time.Time `json:"start"`
+	Open     float64   `json:"open"`
+	High     float64   `json:"high"`
+	Low      float64   `json:"low"`
+	Close    float64   `json:"close"`
+	Ticks    int64     `json:"ticks"`
+	Volume   float64   `json:"volume,omitempty"`
+	Origin   string    `json:"origin,omitempty"`
+
+	OpenAt  int64 `json:"-"` // event time of the open tick, unix ms
+	CloseAt int64 `json:"-"` // event time of the close tick, unix ms
+}
+
+// Add puts the tick into the candle
+func (c *Candle) Add(tick Data) {
+	origin := tick.Origin
+	if origin == "" {
+		origin = OriginLive
+	}
+
+	c.Merge(Candle{
+		Open: tick.Price, High: tick.Price, Low: tick.Price, Close: tick.Price,
+		Ticks: 1, Volume: tick.Quantity, Origin: origin,
+		OpenAt: tick.Timestamp, CloseAt: tick.Timestamp,
+	})
+}
+
+// Merge combines two parts of the same bucket, e.g. the stored candle and ticks which came after it was saved
+func (c *Candle) Merge(other Candle) {
+	if other.Ticks == 0 {
+		return
+	}
+	if c.Ticks == 0 {
+		c.Open, c.High, c.Low, c.Close = other.Open, other.High, other.Low, other.Close
+		c.Ticks, c.Volume, c.Origin = other.Ticks, other.Volume, other.Origin
+		c.OpenAt, c.CloseAt = other.OpenAt, other.CloseAt
+		return
+	}
+
+	if other.OpenAt < c.OpenAt {
+		c.Open, c.OpenAt = other.Open, other.OpenAt
+	}
+	if other.CloseAt >= c.CloseAt {
+		c.Close, c.CloseAt = other.Close, other.CloseAt
+	}
+	if other.High > c.High {
+		c.High = other.High
+	}
+	if other.Low < c.Low {
+		c.Low = other.Low
+	}
+	c.Ticks += other.Ticks
+	c.Volume += other.Volume
+	c.Origin = MergeOrigin(c.Origin, other.Origin)
+}
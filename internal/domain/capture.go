@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// Line of the capture file: parsed tick with the original exchange line
+type CapturedTick struct {
+	Data
+	ReceivedAt int64  `json:"received_at"` // unix ms
+	Raw        string `json:"raw"`
+}
+
+// State of the raw ticks capture
+type CaptureStatus struct {
+	Enabled   bool      `json:"enabled"`
+	File      string    `json:"file,omitempty"`
+	Records   int64     `json:"records"`
+	Bytes     int64     `json:"bytes"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+}
@@ -8,6 +8,30 @@ type Data struct {
 	Symbol       string  `json:"symbol"`
 	Price        float64 `json:"price"`
 	Timestamp    int64   `json:"timestamp,omitempty"`
+	Origin       string  `json:"origin,omitempty"`
+
+	// Optional fields, zero means the feed didn't send them
+	Quantity float64 `json:"quantity,omitempty"`
+	Bid      float64 `json:"bid,omitempty"` // best bid
+	Ask      float64 `json:"ask,omitempty"` // best ask
+}
+
+// Origin of the data, aggregates made of both live and synthetic ticks are mixed
+const (
+	OriginLive      = "live"
+	OriginSynthetic = "synthetic"
+	OriginMixed     = "mixed"
+)
+
+// MergeOrigin returns origin of data combined from both origins, empty origin is ignored
+func MergeOrigin(a, b string) string {
+	switch {
+	case a == "" || a == b:
+		return b
+	case b == "":
+		return a
+	}
+	return OriginMixed
 }
 
 // Aggregated data
@@ -18,6 +42,69 @@ type ExchangeData struct {
 	Average_price float64   `json:"average_price"`
 	Min_price     float64   `json:"min_price"`
 	Max_price     float64   `json:"max_price"`
+	Origin        string    `json:"origin,omitempty"`
+	Volume        float64   `json:"volume,omitempty"`      // sum of ticks quantity
+	Average_bid   float64   `json:"average_bid,omitempty"` // bid and ask are averaged over ticks which have both of them,
+	Average_ask   float64   `json:"average_ask,omitempty"` // so Average_ask - Average_bid is the average spread
+
+	// Components of the average price, see PriceAverage
+	Price_sum     float64 `json:"price_sum,omitempty"`
+	Tick_count    int64   `json:"tick_count,omitempty"`
+	Weighted_sum  float64 `json:"weighted_sum,omitempty"`
+	Weighted_time int64   `json:"weighted_time,omitempty"`
+
+	// Components of the average bid and ask, see Quotes
+	Bid_sum     float64 `json:"bid_sum,omitempty"`
+	Ask_sum     float64 `json:"ask_sum,omitempty"`
+	Quote_count int64   `json:"quote_count,omitempty"`
+}
+
+// Average returns components of the average price, aggregates without tick count count as one tick
+func (ed ExchangeData) Average() PriceAverage {
+	avg := PriceAverage{Sum: ed.Price_sum, Count: ed.Tick_count, WeightedSum: ed.Weighted_sum, WeightedTime: ed.Weighted_time, Origin: ed.Origin}
+	if avg.Count == 0 {
+		avg.Sum, avg.Count = ed.Average_price, 1
+	}
+	return avg
+}
+
+// Quotes returns sums of bids and asks and the number of ticks which have both of them,
+// aggregates without quote count count as one tick if they have average quotes
+func (ed ExchangeData) Quotes() (float64, float64, int64) {
+	if ed.Quote_count == 0 && ed.Average_bid > 0 && ed.Average_ask > 0 {
+		return ed.Average_bid, ed.Average_ask, 1
+	}
+	return ed.Bid_sum, ed.Ask_sum, ed.Quote_count
+}
+
+// Connection settings of a single exchange
+type ExchangeConfig struct {
+	Name string // name used in API paths and storage (e.g. Exchange1)
+	Host string
+	Port string
+	URL  string // ws:// or wss:// address, the exchange is read over WebSocket instead of TCP
+
+	Subscribe    string        // WebSocket message sent after connect, {symbol} and {symbol_lower} are replaced per active symbol
+	PingInterval time.Duration // WebSocket keepalive period
+
+	SymbolAliases map[string]string // native symbol of the exchange (upper cased) -> canonical symbol
+	SymbolNatives map[string]string // canonical symbol -> native symbol used in subscriptions, the first alias wins
+	Decoder       string            // wire format of the exchange messages, empty is JSON lines
+}
+
+// Address returns "host:port" or the WebSocket URL of the exchange
+func (c ExchangeConfig) Address() string {
+	if c.URL != "" {
+		return c.URL
+	}
+	return c.Host + ":" + c.Port
 }
 
 var Exchanges = []string{"Exchange1", "Exchange2", "Exchange3", "All"}
+
+// SetExchanges replaces the list of valid exchange names, "All" is always kept at the end
+func SetExchanges(names []string) {
+	exchanges := make([]string, 0, len(names)+1)
+	exchanges = append(exchanges, names...)
+	Exchanges = append(exchanges, "All")
+}
@@ -3,10 +3,19 @@ package domain
 import "errors"
 
 var (
-	ErrInvalidExchangeVal             = errors.New("exchange value is invalid , must be (Exchange1, Exchange2, Exchange3, All)")
+	ErrInvalidExchangeVal             = errors.New("exchange value is invalid , must be one of the configured exchanges or All")
 	ErrInvalidMetricVal               = errors.New("metric value is invalid , must be (highest, lowest, latest, average)")
-	ErrInvalidSymbolVal               = errors.New("symbol value is invalid , must be (BTCUSDT, DOGEUSDT, TONUSDT, ETHUSDT, SOLUSDT)")
-	ErrInvalidModeVal                 = errors.New("mode value is invalid, must be (test or live)")
+	ErrInvalidSymbolVal               = errors.New("symbol value is invalid , must be one of the registered symbols (GET /symbols)")
+	ErrInvalidSymbolName              = errors.New("symbol name is invalid, must be 2-20 uppercase letters and digits")
+	ErrSymbolNotFound                 = errors.New("symbol is not registered")
+	ErrUnauthorized                   = errors.New("admin token is missing or invalid")
+	ErrIngestUnauthorized             = errors.New("ingest token is missing or invalid")
+	ErrEmptyIngestBatch               = errors.New("ingest batch has no ticks")
+	ErrIngestBatchTooLarge            = errors.New("ingest batch is larger than the source burst limit")
+	ErrIngestRateLimited              = errors.New("ingest rate limit of the source is exceeded")
+	ErrIngestBusy                     = errors.New("ingest pipeline is busy, retry later")
+	ErrInvalidModeVal                 = errors.New("mode value is invalid, must be (test, live, hybrid or replay)")
+	ErrInvalidCaptureAction           = errors.New("capture action is invalid, must be (start or stop)")
 	ErrAllNotSupported                = errors.New(`"All" is not supported for this period-based query`)
 	ErrEmptyMetricVal                 = errors.New("metric value is empty")
 	ErrEmptyExchangeVal               = errors.New("exchange value is empty")
@@ -18,4 +27,16 @@ var (
 	ErrLatestPriceNotFound            = errors.New("latest price is not found")
 	ErrAveragePriceNotFound           = errors.New("average price is not found")
 	ErrAveragePriceWithPeriodNotFound = errors.New("average price data is unavailable for the selected period")
+	ErrInvalidWeightingVal            = errors.New("weighting value is invalid, must be (tick or time)")
+	ErrInvalidCandleInterval          = errors.New("candle interval is invalid, must be (1s, 1m, 5m, 15m, 1h or 1d)")
+	ErrInvalidTimeVal                 = errors.New("time value is invalid, must be RFC3339 time or unix milliseconds")
+	ErrInvalidTimeRange               = errors.New("time range is invalid, from must be before to")
+	ErrPeriodWithTimeRange            = errors.New("period can't be combined with from and to")
+	ErrTooManyCandles                 = errors.New("time range is too long, at most 1000 candles are returned")
+	ErrInvalidPageSize                = errors.New("page size is invalid, must be from 1 to 1000")
+	ErrInvalidPageToken               = errors.New("page token is invalid")
+	ErrInvalidStreamEvent             = errors.New("stream event value is invalid, must be (tick, aggregate or candle)")
+	ErrInvalidChannel                 = errors.New("channel is invalid, must be latest:{exchange}:{symbol}, aggregate:{exchange}:{symbol} or candles:{interval}:{exchange}:{symbol}")
+	ErrTooManyChannels                = errors.New("too many channels, at most 100 are allowed per connection")
+	ErrInvalidThrottleVal             = errors.New("throttle value is invalid, must be a positive duration (e.g. 500ms)")
 )
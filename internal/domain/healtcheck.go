@@ -1,6 +1,33 @@
 package domain
 
+import "time"
+
 type ConnMsg struct {
-	Connection string `json:"connection,omitempty"`
-	Status     string `json:"status"`
+	Connection string          `json:"connection,omitempty"`
+	Status     string          `json:"status"`
+	Exchange   *ExchangeStatus `json:"exchange,omitempty"`
+}
+
+// Connection state of a live exchange
+type ExchangeState string
+
+const (
+	ExchangeConnecting ExchangeState = "connecting"
+	ExchangeStreaming  ExchangeState = "streaming"
+	ExchangeBackingOff ExchangeState = "backing off"
+	ExchangeStopped    ExchangeState = "stopped"
+)
+
+// Connection report of a live exchange
+type ExchangeStatus struct {
+	Name         string               `json:"name"`
+	Address      string               `json:"address"`
+	State        ExchangeState        `json:"state"`
+	LastMessage  time.Time            `json:"last_message,omitempty"`
+	LastTicks    map[string]time.Time `json:"last_ticks,omitempty"` // by symbol
+	Stale        bool                 `json:"stale"`
+	StaleSymbols []string             `json:"stale_symbols,omitempty"`
+	Reconnects   int                  `json:"reconnects"`
+	Synthetic    bool                 `json:"synthetic,omitempty"` // ticks are generated while the exchange is down (hybrid mode)
+	LastError    string               `json:"last_error,omitempty"`
 }
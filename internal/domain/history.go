@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// One stored aggregated row of the price history
+type PricePoint struct {
+	ID            int64     `json:"-"` // row id, orders points stored at the same time
+	Timestamp     time.Time `json:"timestamp"`
+	Average_price float64   `json:"average_price"`
+	Min_price     float64   `json:"min_price"`
+	Max_price     float64   `json:"max_price"`
+	Origin        string    `json:"origin,omitempty"`
+}
+
+// Page of the price history, the next page is requested with the same range and NextPageToken
+type PriceHistory struct {
+	Exchange      string       `json:"exchange"`
+	Symbol        string       `json:"symbol"`
+	Points        []PricePoint `json:"points"`
+	NextPageToken string       `json:"next_page_token,omitempty"` // empty on the last page
+}
@@ -6,13 +6,46 @@ import "time"
 type DataFetcher interface {
 	SetupDataFetcher() (chan map[string]ExchangeData, chan []Data, error)
 	CheckHealth() error
+	ExchangeStatuses() []ExchangeStatus
 	Close()
 }
 
+type TickRecorder interface {
+	Record(data Data, raw string, receivedAt time.Time)
+	Start() error
+	Stop() error
+	Status() CaptureStatus
+}
+
+// Keeps ticks rejected by the ingest pipeline
+type TickQuarantine interface {
+	Put(tick Data, reason string)  // keeps the tick and counts it
+	Count(exchange, reason string) // only counts the tick
+	Report(reason string, limit int) QuarantineReport
+}
+
+// Source of the symbols accepted by the ingest pipeline and the API
+type SymbolRegistry interface {
+	List() []string             // active symbols in alphabetical order
+	Active(symbol string) bool  // ticks of the symbol are accepted
+	Known(symbol string) bool   // symbol is active or retired
+	Observe(symbol string) bool // reports if the symbol is active, unknown symbol is queued for registration when auto-discovery is on
+	All() []Symbol
+	Add(symbol string) (Symbol, error)
+	Retire(symbol string) (Symbol, error)
+}
+
+// Persists changes of the symbol registry
+type SymbolStore interface {
+	LoadSymbols() ([]Symbol, error)
+	SaveSymbol(symbol Symbol) error
+}
+
 type CacheMemory interface {
 	SaveAggregatedData(aggregatedData map[string]ExchangeData) error
 	SaveLatestData(latestData map[string]Data) error
 	GetLatestData(exchange, symbol string) (Data, error)
+	DeleteSymbol(symbol string) error // removes latest and aggregated keys of the symbol
 	CheckHealth() error
 }
 
@@ -21,17 +54,21 @@ type Database interface {
 	SaveLatestData(latestData map[string]Data) error
 	GetLatestDataByExchange(exchange, symbol string) (Data, error)
 	GetLatestDataByAllExchanges(symbol string) (Data, error)
-	GetAveragePriceByExchange(exchange, symbol string) (Data, error)
-	GetAveragePriceByAllExchanges(symbol string) (Data, error)
-	GetAveragePriceWithDuration(exchange, symbol string, startTime time.Time, duration time.Duration) (Data, error)
+	GetAveragePriceByExchange(exchange, symbol string) (PriceAverage, error)
+	GetAveragePriceByAllExchanges(symbol string) (PriceAverage, error)
+	GetAveragePriceInRange(exchange, symbol string, from, to time.Time) (PriceAverage, error)
+	GetAveragePriceByAllExchangesInRange(symbol string, from, to time.Time) (PriceAverage, error)
 	GetMinPriceByAllExchanges(symbol string) (Data, error)
 	GetMinPriceByExchange(exchange, symbol string) (Data, error)
-	GetMinPriceByExchangeWithDuration(exchange, symbol string, startTime time.Time, duration time.Duration) (Data, error)
-	GetMinPriceByAllExchangesWithDuration(symbol string, startTime time.Time, duration time.Duration) (Data, error)
+	GetMinPriceByExchangeInRange(exchange, symbol string, from, to time.Time) (Data, error)
+	GetMinPriceByAllExchangesInRange(symbol string, from, to time.Time) (Data, error)
 	GetMaxPriceByAllExchanges(symbol string) (Data, error)
 	GetMaxPriceByExchange(exchange, symbol string) (Data, error)
-	GetMaxPriceByExchangeWithDuration(exchange, symbol string, startTime time.Time, duration time.Duration) (Data, error)
-	GetMaxPriceByAllExchangesWithDuration(symbol string, startTime time.Time, duration time.Duration) (Data, error)
+	GetMaxPriceByExchangeInRange(exchange, symbol string, from, to time.Time) (Data, error)
+	GetMaxPriceByAllExchangesInRange(symbol string, from, to time.Time) (Data, error)
+	SaveCandles(candles []Candle) error // merges candles into the stored ones of the same bucket
+	GetCandles(exchange, symbol, interval string, from, to time.Time) ([]Candle, error)
+	GetPriceHistory(exchange, symbol string, from, to time.Time, after PricePoint, limit int) ([]PricePoint, error) // points after the given one in (time, id) order
 	CheckHealth() error
 }
 
@@ -39,17 +76,35 @@ type Database interface {
 type DataModeService interface {
 	GetAggregatedDataByDuration(exchange, symbol string, duration time.Duration) []map[string]ExchangeData
 	GetLatestData(exchange string, symbol string) (Data, int, error)
-	GetAveragePrice(exchange, symbol string) (Data, int, error)
-	GetAveragePriceWithPeriod(exchange, symbol, period string) (Data, int, error)
+	GetAveragePrice(exchange, symbol, weighting string) (Data, int, error)
+	GetAveragePriceWithPeriod(exchange, symbol, period, weighting string) (Data, int, error)
+	GetAveragePriceByAllExchangesWithPeriod(symbol, period, weighting string) (Data, int, error)
+	GetAveragePriceInRange(exchange, symbol, from, to, weighting string) (Data, int, error)
 	GetHighestPrice(exchange, symbol string) (Data, int, error)
 	GetHighestPriceWithPeriod(exchange, symbol string, period string) (Data, int, error)
 	GetHighestPriceByAllExchangesWithPeriod(symbol string, period string) (Data, int, error)
+	GetHighestPriceInRange(exchange, symbol, from, to string) (Data, int, error)
 	GetLowestPrice(exchange, symbol string) (Data, int, error)
 	GetLowestPriceWithPeriod(exchange, symbol string, period string) (Data, int, error)
 	GetLowestPriceByAllExchangesWithPeriod(symbol string, period string) (Data, int, error)
+	GetLowestPriceInRange(exchange, symbol, from, to string) (Data, int, error)
+	GetCandles(exchange, symbol, interval, from, to string) ([]Candle, int, error)
+	GetPriceHistory(exchange, symbol, from, to, pageToken, pageSize string) (PriceHistory, int, error)
 	SaveLatestData(rawDataCh chan []Data)
+	SubscribePrices(filter StreamFilter) (PriceSubscription, int, error)
+	SubscribeChannels(buffer int, slowClient string) ChannelSubscription
 	SwitchMode(mode string) (int, error)
+	SwitchToTestScenario(file string) (int, error)
+	SwitchToReplayMode(file, speed string) (int, error)
+	SwitchCapture(action string) (CaptureStatus, int, error)
+	CaptureStatus() CaptureStatus
 	CheckHealth() []ConnMsg
+	QuarantineReport(reason string, limit int) QuarantineReport
+	ExchangeStatuses() []ExchangeStatus
+	ListSymbols() []Symbol
+	AddSymbol(symbol string) (Symbol, int, error)
+	RetireSymbol(symbol string) (Symbol, int, error)
+	IngestTicks(source string, ticks []Data) (int, int, error)
 	ListenAndSave() error
 	StopListening()
 }
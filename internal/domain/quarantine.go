@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// Reasons of ticks removal from the ingest pipeline
+const (
+	ReasonLate          = "late"
+	ReasonInvalidPrice  = "invalid_price"
+	ReasonInvalidQuote  = "invalid_quote" // negative quantity, bid or ask, or bid above ask
+	ReasonUnknownSymbol = "unknown_symbol"
+	ReasonUnmapped      = "unmapped_symbol" // native symbol of the exchange has no canonical symbol
+	ReasonOutlier       = "outlier"
+	ReasonFuture        = "future_timestamp" // exchange clock is ahead of the local one by more than the allowed skew
+	ReasonDuplicate     = "duplicate"
+)
+
+// Tick removed from the ingest pipeline
+type QuarantinedTick struct {
+	Data
+	RawPrice    string    `json:"raw_price,omitempty"` // NaN and infinite prices can't be sent as JSON numbers
+	RawQuantity string    `json:"raw_quantity,omitempty"`
+	RawBid      string    `json:"raw_bid,omitempty"`
+	RawAsk      string    `json:"raw_ask,omitempty"`
+	Reason      string    `json:"reason"`
+	At          time.Time `json:"quarantined_at"`
+}
+
+// Counters and kept ticks of the quarantine
+type QuarantineReport struct {
+	Counts map[string]map[string]int64 `json:"counts"` // by exchange and reason
+	Ticks  []QuarantinedTick           `json:"ticks"`
+}
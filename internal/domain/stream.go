@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of the live price events
+const (
+	EventTick      = "tick"      // every accepted tick
+	EventAggregate = "aggregate" // closed aggregation window of an exchange or All, one second by default
+	EventCandle    = "candle"    // candle of an exchange or All changed by a tick
+)
+
+var StreamEvents = []string{EventTick, EventAggregate, EventCandle}
+
+// Event of the live price stream, Tick, Aggregate or Candle is set by the event type
+type PriceEvent struct {
+	Type      string        `json:"type"`
+	Exchange  string        `json:"exchange"`
+	Symbol    string        `json:"symbol"`
+	Interval  string        `json:"interval,omitempty"` // candle interval
+	Tick      *Data         `json:"tick,omitempty"`
+	Aggregate *ExchangeData `json:"aggregate,omitempty"`
+	Candle    *Candle       `json:"candle,omitempty"`
+}
+
+// Channel kinds of the WebSocket API
+const (
+	ChannelLatest    = "latest"    // latest:{exchange}:{symbol}, ticks of the exchange or of every exchange for All
+	ChannelAggregate = "aggregate" // aggregate:{exchange}:{symbol}
+	ChannelCandles   = "candles"   // candles:{interval}:{exchange}:{symbol}
+)
+
+// Channels returns names of the channels the event belongs to
+func (ev PriceEvent) Channels() []string {
+	switch ev.Type {
+	case EventTick:
+		return []string{ChannelLatest + ":" + ev.Exchange + ":" + ev.Symbol, ChannelLatest + ":All:" + ev.Symbol}
+	case EventAggregate:
+		return []string{ChannelAggregate + ":" + ev.Exchange + ":" + ev.Symbol}
+	case EventCandle:
+		return []string{ChannelCandles + ":" + ev.Interval + ":" + ev.Exchange + ":" + ev.Symbol}
+	}
+	return nil
+}
+
+// Payload returns the data of the event
+func (ev PriceEvent) Payload() any {
+	switch ev.Type {
+	case EventTick:
+		return ev.Tick
+	case EventAggregate:
+		return ev.Aggregate
+	case EventCandle:
+		return ev.Candle
+	}
+	return nil
+}
+
+// Channel is a parsed channel name
+type Channel struct {
+	Kind     string
+	Interval string // only candles
+	Exchange string
+	Symbol   string
+}
+
+// ParseChannel splits the channel name, exchange and symbol are not checked
+func ParseChannel(name string) (Channel, error) {
+	parts := strings.Split(name, ":")
+	switch {
+	case len(parts) == 3 && (parts[0] == ChannelLatest || parts[0] == ChannelAggregate):
+		return Channel{Kind: parts[0], Exchange: parts[1], Symbol: parts[2]}, nil
+	case len(parts) == 4 && parts[0] == ChannelCandles:
+		if _, ok := CandleDuration(parts[1]); !ok {
+			return Channel{}, fmt.Errorf("%s: %w", name, ErrInvalidCandleInterval)
+		}
+		return Channel{Kind: parts[0], Interval: parts[1], Exchange: parts[2], Symbol: parts[3]}, nil
+	}
+	return Channel{}, fmt.Errorf("%s: %w", name, ErrInvalidChannel)
+}
+
+// Selects events of a stream subscription, empty list matches everything
+type StreamFilter struct {
+	Symbols   []string
+	Exchanges []string
+	Events    []string
+}
+
+// What happens to a subscriber which buffer is full
+const (
+	SlowClientDrop       = "drop"       // new events are skipped
+	SlowClientDisconnect = "disconnect" // the subscription is closed
+)
+
+// Live events of one subscriber, Events is closed when the stream is shut down or the slow subscriber is disconnected
+type PriceSubscription interface {
+	Events() <-chan PriceEvent
+	Dropped() int64   // events skipped because the subscriber was too slow
+	Overflowed() bool // the subscription was closed because its buffer was full
+	Close()
+}
+
+// Subscription which channels are changed at runtime, it starts without channels
+type ChannelSubscription interface {
+	PriceSubscription
+	Subscribe(channels []string) error // validates all channels before subscribing to any of them
+	Unsubscribe(channels []string)
+	Channels() []string // subscribed channels in alphabetical order
+	Has(channel string) bool
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 const (
 	BTCUSDT  string = "BTCUSDT"
 	DOGEUSDT string = "DOGEUSDT"
@@ -8,4 +10,13 @@ const (
 	ETHUSDT  string = "ETHUSDT"
 )
 
-var Symbols = []string{BTCUSDT, DOGEUSDT, TONUSDT, SOLUSDT, ETHUSDT}
+// Symbols of the registry when SYMBOLS is not configured
+var DefaultSymbols = []string{BTCUSDT, DOGEUSDT, TONUSDT, SOLUSDT, ETHUSDT}
+
+// Trading pair known by the symbol registry
+type Symbol struct {
+	Name       string    `json:"symbol"`
+	Active     bool      `json:"active"`               // retired symbols are rejected by the ingest pipeline but their history is queryable
+	Discovered bool      `json:"discovered,omitempty"` // added automatically after it was seen on a feed
+	UpdatedAt  time.Time `json:"updated_at"`
+}
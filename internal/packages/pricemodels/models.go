@@ -0,0 +1,112 @@
+// Package pricemodels contains stochastic price generators for synthetic market data
+package pricemodels
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Seconds in a year, model parameters are annualized
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// Model names
+const (
+	Uniform       = "uniform" // independent noise around the base price
+	GBM           = "gbm"     // geometric Brownian motion
+	MeanReverting = "ou"      // Ornstein-Uhlenbeck process on the log price
+	JumpDiffusion = "jump"    // Merton jump-diffusion
+)
+
+// Params of one symbol, all rates are annual
+type Params struct {
+	Base       float64 // starting price, also the long-term mean of the mean-reverting model
+	Drift      float64 // expected return
+	Volatility float64 // standard deviation of returns
+	Reversion  float64 // mean reversion speed of the mean-reverting model
+	JumpRate   float64 // expected jumps number of the jump-diffusion model
+	JumpMean   float64 // mean of log jump size
+	JumpStd    float64 // standard deviation of log jump size
+}
+
+// Model moves the price forward
+type Model interface {
+	// Next returns the price after dt seconds
+	Next(price, dt float64, rng *rand.Rand) float64
+}
+
+// New returns model by its name
+func New(name string, params Params) (Model, error) {
+	switch name {
+	case Uniform:
+		return uniformModel{params}, nil
+	case GBM:
+		return gbmModel{params}, nil
+	case MeanReverting:
+		return ouModel{params}, nil
+	case JumpDiffusion:
+		return jumpModel{params}, nil
+	}
+	return nil, fmt.Errorf("unknown price model: %s", name)
+}
+
+// ±15% uniform noise around the base price, the series has no memory
+type uniformModel struct{ Params }
+
+func (m uniformModel) Next(price, dt float64, rng *rand.Rand) float64 {
+	return m.Base * (1 + (rng.Float64()-0.5)*0.3)
+}
+
+type gbmModel struct{ Params }
+
+func (m gbmModel) Next(price, dt float64, rng *rand.Rand) float64 {
+	t := dt / secondsPerYear
+	return price * math.Exp((m.Drift-m.Volatility*m.Volatility/2)*t+m.Volatility*math.Sqrt(t)*rng.NormFloat64())
+}
+
+// Exact discretization of dx = reversion*(ln(base) - x)dt + volatility*dW, x = ln(price)
+type ouModel struct{ Params }
+
+func (m ouModel) Next(price, dt float64, rng *rand.Rand) float64 {
+	t := dt / secondsPerYear
+	x, mean := math.Log(price), math.Log(m.Base)
+
+	if m.Reversion <= 0 {
+		return math.Exp(x + m.Volatility*math.Sqrt(t)*rng.NormFloat64())
+	}
+
+	decay := math.Exp(-m.Reversion * t)
+	std := m.Volatility * math.Sqrt((1-decay*decay)/(2*m.Reversion))
+	return math.Exp(mean + (x-mean)*decay + std*rng.NormFloat64())
+}
+
+// GBM with Poisson jumps of log-normal size
+type jumpModel struct{ Params }
+
+func (m jumpModel) Next(price, dt float64, rng *rand.Rand) float64 {
+	t := dt / secondsPerYear
+
+	// Drift is compensated, so jumps don't change the expected return
+	kappa := math.Exp(m.JumpMean+m.JumpStd*m.JumpStd/2) - 1
+	logReturn := (m.Drift-m.JumpRate*kappa-m.Volatility*m.Volatility/2)*t + m.Volatility*math.Sqrt(t)*rng.NormFloat64()
+
+	for n := poisson(m.JumpRate*t, rng); n > 0; n-- {
+		logReturn += m.JumpMean + m.JumpStd*rng.NormFloat64()
+	}
+	return price * math.Exp(logReturn)
+}
+
+// Knuth algorithm, lambda is small for one tick
+func poisson(lambda float64, rng *rand.Rand) int {
+	if lambda <= 0 {
+		return 0
+	}
+
+	limit := math.Exp(-lambda)
+	n, p := 0, rng.Float64()
+	for p > limit {
+		n++
+		p *= rng.Float64()
+	}
+	return n
+}
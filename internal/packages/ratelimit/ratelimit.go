@@ -0,0 +1,56 @@
+// Package ratelimit has token buckets keyed by client name
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// Limiter refills every key bucket with rate tokens per second up to burst
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func New(rate float64, burst int) *Limiter {
+	return &Limiter{rate: rate, burst: float64(burst), buckets: make(map[string]*bucket)}
+}
+
+// Burst returns the largest number of tokens which could be taken at once
+func (l *Limiter) Burst() int {
+	return int(l.burst)
+}
+
+// AllowN takes n tokens from the key bucket, nothing is taken if the bucket has less than n tokens
+func (l *Limiter) AllowN(key string, n int, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, at: now}
+		l.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.at).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * l.rate
+		if b.tokens > l.burst {
+			b.tokens = l.burst
+		}
+	}
+	b.at = now
+
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
@@ -7,31 +7,36 @@ import (
 	"time"
 )
 
-// Fetches the average price for a specific exchange and symbol
-func (serv *DataModeServiceImp) GetAveragePrice(exchange, symbol string) (domain.Data, int, error) {
+// Fetches the average price for a specific exchange and symbol, weighting is tick (default) or time
+func (serv *DataModeServiceImp) GetAveragePrice(exchange, symbol, weighting string) (domain.Data, int, error) {
 	var (
-		data domain.Data
-		err  error
+		avg domain.PriceAverage
+		err error
 	)
 
 	if err := CheckExchangeName(exchange); err != nil {
-		return data, http.StatusBadRequest, err
+		return domain.Data{}, http.StatusBadRequest, err
 	}
 
-	if err := CheckSymbolName(symbol); err != nil {
-		return data, http.StatusBadRequest, err
+	if err := serv.CheckSymbolName(symbol); err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+
+	weighting, err = domain.CheckWeighting(weighting)
+	if err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
 	}
 
 	switch exchange {
 	case "All":
-		data, err = serv.DB.GetAveragePriceByAllExchanges(symbol)
+		avg, err = serv.DB.GetAveragePriceByAllExchanges(symbol)
 		if err != nil {
-			return data, http.StatusInternalServerError, err
+			return domain.Data{}, http.StatusInternalServerError, err
 		}
 	default:
-		data, err = serv.DB.GetAveragePriceByExchange(exchange, symbol)
+		avg, err = serv.DB.GetAveragePriceByExchange(exchange, symbol)
 		if err != nil {
-			return data, http.StatusInternalServerError, err
+			return domain.Data{}, http.StatusInternalServerError, err
 		}
 	}
 
@@ -40,70 +45,176 @@ func (serv *DataModeServiceImp) GetAveragePrice(exchange, symbol string) (domain
 	merged := MergeAggregatedData(serv.DataBuffer)
 	serv.mu.Unlock()
 
-	data.Timestamp = time.Now().UnixMilli()
 	key := exchange + " " + symbol
-	if avg, ok := merged[key]; ok {
-		if avg.Average_price != 0 {
-			data.Price = (avg.Average_price + data.Price) / 2
-		}
+	if agg, ok := merged[key]; ok {
+		avg.Add(agg.Average())
 	} else {
 		slog.Warn("Aggregated data not found for key", "key", key)
 	}
 
-	if data.Price == 0 {
+	price, ok := avg.Value(weighting)
+	if !ok {
 		return domain.Data{}, http.StatusNotFound, domain.ErrAveragePriceNotFound
 	}
 
-	return data, http.StatusOK, nil
+	return domain.Data{
+		ExchangeName: exchange,
+		Symbol:       symbol,
+		Price:        price,
+		Timestamp:    time.Now().UnixMilli(),
+		Origin:       avg.Origin,
+	}, http.StatusOK, nil
 }
 
-// Fetches the average price for a specific exchange and symbol over a given period
-func (serv *DataModeServiceImp) GetAveragePriceWithPeriod(exchange, symbol, period string) (domain.Data, int, error) {
-	var (
-		data domain.Data
-		err  error
-	)
-
+// Fetches the average price for a specific exchange and symbol over a given period, weighting is tick (default) or time
+func (serv *DataModeServiceImp) GetAveragePriceWithPeriod(exchange, symbol, period, weighting string) (domain.Data, int, error) {
 	if err := CheckExchangeName(exchange); err != nil {
-		return data, http.StatusBadRequest, err
+		return domain.Data{}, http.StatusBadRequest, err
 	}
 
-	if err := CheckSymbolName(symbol); err != nil {
-		return data, http.StatusBadRequest, err
+	if err := serv.CheckSymbolName(symbol); err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
 	}
 
 	if exchange == "All" {
-		return data, http.StatusBadRequest, domain.ErrAllNotSupported
+		return domain.Data{}, http.StatusBadRequest, domain.ErrAllNotSupported
+	}
+
+	weighting, err := domain.CheckWeighting(weighting)
+	if err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
 	}
 
 	duration, err := time.ParseDuration(period)
 	if err != nil {
-		return data, http.StatusBadRequest, err
+		return domain.Data{}, http.StatusBadRequest, err
 	}
 	startTime := time.Now()
 
-	data, err = serv.DB.GetAveragePriceWithDuration(exchange, symbol, startTime, duration)
+	avg, err := serv.DB.GetAveragePriceInRange(exchange, symbol, startTime.Add(-duration), startTime)
 	if err != nil {
-		return data, http.StatusInternalServerError, err
+		return domain.Data{}, http.StatusInternalServerError, err
+	}
+
+	aggregated := serv.GetAggregatedDataByDuration(exchange, symbol, duration)
+	merged := MergeAggregatedData(aggregated)
+
+	key := exchange + " " + symbol
+	if agg, ok := merged[key]; ok {
+		avg.Add(agg.Average())
+	} else {
+		slog.Warn("Aggregated data not found for key", "key", key)
+	}
+
+	price, ok := avg.Value(weighting)
+	if !ok {
+		return domain.Data{}, http.StatusNotFound, domain.ErrAveragePriceWithPeriodNotFound
+	}
+
+	return domain.Data{
+		ExchangeName: exchange,
+		Symbol:       symbol,
+		Price:        price,
+		Timestamp:    startTime.Add(-duration).UnixMilli(),
+		Origin:       avg.Origin,
+	}, http.StatusOK, nil
+}
+
+// Fetches the average price of a symbol across all exchanges over a given period, weighting is tick (default) or time
+func (serv *DataModeServiceImp) GetAveragePriceByAllExchangesWithPeriod(symbol, period, weighting string) (domain.Data, int, error) {
+	exchange := "All"
+	if err := serv.CheckSymbolName(symbol); err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
 	}
 
-	data.Timestamp = startTime.Add(-duration).UnixMilli()
+	weighting, err := domain.CheckWeighting(weighting)
+	if err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+
+	duration, err := time.ParseDuration(period)
+	if err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+	startTime := time.Now()
+
+	avg, err := serv.DB.GetAveragePriceByAllExchangesInRange(symbol, startTime.Add(-duration), startTime)
+	if err != nil {
+		slog.Error("Failed to get average price of all exchanges by period", "error", err.Error())
+		return domain.Data{}, http.StatusInternalServerError, err
+	}
 
 	aggregated := serv.GetAggregatedDataByDuration(exchange, symbol, duration)
 	merged := MergeAggregatedData(aggregated)
 
 	key := exchange + " " + symbol
 	if agg, ok := merged[key]; ok {
-		if agg.Average_price != 0 {
-			data.Price = (agg.Average_price + data.Price) / 2
-		}
+		avg.Add(agg.Average())
 	} else {
 		slog.Warn("Aggregated data not found for key", "key", key)
 	}
 
-	if data.Price == 0 {
+	price, ok := avg.Value(weighting)
+	if !ok {
+		return domain.Data{}, http.StatusNotFound, domain.ErrAveragePriceWithPeriodNotFound
+	}
+
+	return domain.Data{
+		ExchangeName: exchange,
+		Symbol:       symbol,
+		Price:        price,
+		Timestamp:    startTime.Add(-duration).UnixMilli(),
+		Origin:       avg.Origin,
+	}, http.StatusOK, nil
+}
+
+// Fetches the average price of the exchange (or All) and symbol within [from, to), weighting is tick (default) or time
+func (serv *DataModeServiceImp) GetAveragePriceInRange(exchange, symbol, from, to, weighting string) (domain.Data, int, error) {
+	if err := CheckExchangeName(exchange); err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+
+	if err := serv.CheckSymbolName(symbol); err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+
+	weighting, err := domain.CheckWeighting(weighting)
+	if err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+
+	start, end, err := ParseTimeRange(from, to)
+	if err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+
+	var avg domain.PriceAverage
+	switch exchange {
+	case "All":
+		avg, err = serv.DB.GetAveragePriceByAllExchangesInRange(symbol, start, end)
+	default:
+		avg, err = serv.DB.GetAveragePriceInRange(exchange, symbol, start, end)
+	}
+	if err != nil {
+		slog.Error("Failed to get average price by time range", "error", err.Error())
+		return domain.Data{}, http.StatusInternalServerError, err
+	}
+
+	merged := MergeAggregatedData(serv.aggregatedDataInRange(exchange, symbol, start, end))
+	if agg, ok := merged[exchange+" "+symbol]; ok {
+		avg.Add(agg.Average())
+	}
+
+	price, ok := avg.Value(weighting)
+	if !ok {
 		return domain.Data{}, http.StatusNotFound, domain.ErrAveragePriceWithPeriodNotFound
 	}
 
-	return data, http.StatusOK, nil
+	return domain.Data{
+		ExchangeName: exchange,
+		Symbol:       symbol,
+		Price:        price,
+		Timestamp:    start.UnixMilli(),
+		Origin:       avg.Origin,
+	}, http.StatusOK, nil
 }
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"marketflow/internal/domain"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// Candles are saved when their bucket ended this long ago, later ticks are merged into the stored candle
+	candleGrace = 5 * time.Second
+	// Default and maximum number of candles of one query
+	defaultCandles = 100
+	maxCandles     = 1000
+)
+
+type candleKey struct {
+	exchange, symbol, interval string
+	start                      int64 // unix ms
+}
+
+// Builds candles of every interval from the validated ticks, candles stay in memory until they are saved
+//
+// Candles are built from the ticks as they arrive, so LATE_TICKS_POLICY is not applied to them:
+// a late tick dropped by the aggregation windows is still counted in its candle
+type candleBuilder struct {
+	mu     sync.Mutex
+	open   map[candleKey]*domain.Candle
+	saving map[candleKey]*domain.Candle // flushed candles until their save is finished
+}
+
+func newCandleBuilder() *candleBuilder {
+	return &candleBuilder{open: make(map[candleKey]*domain.Candle), saving: make(map[candleKey]*domain.Candle)}
+}
+
+func keyOf(c domain.Candle) candleKey {
+	return candleKey{exchange: c.Exchange, symbol: c.Symbol, interval: c.Interval, start: c.Start.UnixMilli()}
+}
+
+// Adds the ticks to the candles of their exchange and All, returns copies of the changed candles if changed is set
+func (b *candleBuilder) Add(ticks []domain.Data, now time.Time, changed bool) []domain.Candle {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var touched map[candleKey]struct{}
+	if changed {
+		touched = make(map[candleKey]struct{})
+	}
+
+	for _, tick := range ticks {
+		if tick.Timestamp == 0 {
+			tick.Timestamp = now.UnixMilli()
+		}
+
+		for _, interval := range domain.CandleIntervals {
+			size, _ := domain.CandleDuration(interval)
+			start := tick.Timestamp - tick.Timestamp%size.Milliseconds()
+			for _, exchange := range []string{tick.ExchangeName, "All"} {
+				key := candleKey{exchange: exchange, symbol: tick.Symbol, interval: interval, start: start}
+				candle, ok := b.open[key]
+				if !ok {
+					candle = &domain.Candle{Exchange: exchange, Symbol: tick.Symbol, Interval: interval, Start: time.UnixMilli(start).UTC()}
+					b.open[key] = candle
+				}
+				candle.Add(tick)
+				if changed {
+					touched[key] = struct{}{}
+				}
+			}
+		}
+	}
+
+	candles := make([]domain.Candle, 0, len(touched))
+	for key := range touched {
+		candles = append(candles, *b.open[key])
+	}
+	return candles
+}
+
+// Returns candles which buckets ended before the time, they are kept as saving until Saved or Restore is called
+func (b *candleBuilder) Flush(before time.Time) []domain.Candle {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	flushed := make([]domain.Candle, 0)
+	for key, candle := range b.open {
+		size, _ := domain.CandleDuration(key.interval)
+		if !candle.Start.Add(size).After(before) {
+			flushed = append(flushed, *candle)
+			delete(b.open, key)
+			if saving, ok := b.saving[key]; ok {
+				saving.Merge(*candle)
+			} else {
+				b.saving[key] = candle
+			}
+		}
+	}
+	return flushed
+}
+
+// Saved forgets the flushed candles after they are stored
+func (b *candleBuilder) Saved(candles []domain.Candle) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, candle := range candles {
+		delete(b.saving, keyOf(candle))
+	}
+}
+
+// Restore puts the flushed candles back after a failed save, they are saved with the next flush
+func (b *candleBuilder) Restore(candles []domain.Candle) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, candle := range candles {
+		key := keyOf(candle)
+		delete(b.saving, key)
+		if open, ok := b.open[key]; ok {
+			open.Merge(candle)
+		} else {
+			b.open[key] = &candle
+		}
+	}
+}
+
+// Returns copies of the candles in memory which start within [from, to), including the ones being saved
+func (b *candleBuilder) Candles(exchange, symbol, interval string, from, to time.Time) []domain.Candle {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	byKey := make(map[candleKey]domain.Candle)
+	for _, candles := range []map[candleKey]*domain.Candle{b.saving, b.open} {
+		for key, candle := range candles {
+			if key.exchange == exchange && key.symbol == symbol && key.interval == interval &&
+				!candle.Start.Before(from) && candle.Start.Before(to) {
+				merged := byKey[key]
+				merged.Merge(*candle)
+				merged.Exchange, merged.Symbol, merged.Interval, merged.Start = candle.Exchange, candle.Symbol, candle.Interval, candle.Start
+				byKey[key] = merged
+			}
+		}
+	}
+
+	result := make([]domain.Candle, 0, len(byKey))
+	for _, candle := range byKey {
+		result = append(result, candle)
+	}
+	return result
+}
+
+// Saves candles which buckets are over, all candles are saved if force is set
+func (serv *DataModeServiceImp) saveCandles(force bool) {
+	before := time.Now().Add(-candleGrace)
+	if force {
+		before = time.Now().Add(48 * time.Hour)
+	}
+
+	candles := serv.candles.Flush(before)
+	if len(candles) == 0 {
+		return
+	}
+	if err := serv.DB.SaveCandles(candles); err != nil {
+		slog.Error("Failed to save candles, they are kept for the next save", "candles", len(candles), "error", err.Error())
+		serv.candles.Restore(candles)
+		return
+	}
+	serv.candles.Saved(candles)
+}
+
+// Returns candles of the exchange (or All) and symbol within [from, to), candles without ticks are skipped
+//
+// Interval is one of domain.CandleIntervals (default 1m), from and to are RFC3339 times or unix milliseconds.
+// By default to is now and from is 100 intervals before it, from is aligned down to the bucket boundary
+func (serv *DataModeServiceImp) GetCandles(exchange, symbol, interval, from, to string) ([]domain.Candle, int, error) {
+	if err := CheckExchangeName(exchange); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err := serv.CheckSymbolName(symbol); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	if interval == "" {
+		interval = "1m"
+	}
+	size, ok := domain.CandleDuration(interval)
+	if !ok {
+		return nil, http.StatusBadRequest, domain.ErrInvalidCandleInterval
+	}
+
+	end := time.Now()
+	if to != "" {
+		t, err := ParseTime(to)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("to: %w", err)
+		}
+		end = t
+	}
+	start := end.Add(-defaultCandles * size)
+	if from != "" {
+		t, err := ParseTime(from)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("from: %w", err)
+		}
+		start = t
+	}
+	start = time.UnixMilli(start.UnixMilli() - start.UnixMilli()%size.Milliseconds())
+
+	switch {
+	case !start.Before(end):
+		return nil, http.StatusBadRequest, domain.ErrInvalidTimeRange
+	case end.Sub(start) > maxCandles*size:
+		return nil, http.StatusBadRequest, domain.ErrTooManyCandles
+	}
+
+	stored, err := serv.DB.GetCandles(exchange, symbol, interval, start, end)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	// Ticks which came after the candle was saved are kept in memory until the next save
+	byStart := make(map[int64]domain.Candle, len(stored))
+	for _, candle := range stored {
+		byStart[candle.Start.UnixMilli()] = candle
+	}
+	for _, candle := range serv.candles.Candles(exchange, symbol, interval, start, end) {
+		merged := byStart[candle.Start.UnixMilli()]
+		merged.Merge(candle)
+		merged.Exchange, merged.Symbol, merged.Interval, merged.Start = candle.Exchange, candle.Symbol, candle.Interval, candle.Start
+		byStart[candle.Start.UnixMilli()] = merged
+	}
+
+	candles := make([]domain.Candle, 0, len(byStart))
+	for _, candle := range byStart {
+		candles = append(candles, candle)
+	}
+	sort.Slice(candles, func(i, j int) bool { return candles[i].Start.Before(candles[j].Start) })
+	return candles, http.StatusOK, nil
+}
+
+// ParseTime accepts RFC3339 time or unix milliseconds
+func ParseTime(val string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, domain.ErrInvalidTimeVal
+	}
+	return t, nil
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"marketflow/internal/domain"
+	"net/http"
+)
+
+// Starts or stops raw ticks capture
+func (serv *DataModeServiceImp) SwitchCapture(action string) (domain.CaptureStatus, int, error) {
+	var err error
+	switch action {
+	case "start":
+		err = serv.Recorder.Start()
+	case "stop":
+		err = serv.Recorder.Stop()
+	default:
+		return domain.CaptureStatus{}, http.StatusBadRequest, domain.ErrInvalidCaptureAction
+	}
+
+	if err != nil {
+		return domain.CaptureStatus{}, http.StatusBadRequest, err
+	}
+	return serv.Recorder.Status(), http.StatusOK, nil
+}
+
+// Returns current state of raw ticks capture
+func (serv *DataModeServiceImp) CaptureStatus() domain.CaptureStatus {
+	return serv.Recorder.Status()
+}
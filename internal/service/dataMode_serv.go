@@ -7,6 +7,7 @@ import (
 	datafetcher "marketflow/internal/adapters/dataFetcher"
 	"marketflow/internal/domain"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 )
@@ -15,20 +16,32 @@ type DataModeServiceImp struct {
 	Datafetcher domain.DataFetcher
 	DB          domain.Database
 	Cache       domain.CacheMemory
+	Recorder    domain.TickRecorder
+	Quarantine  domain.TickQuarantine
+	Symbols     domain.SymbolRegistry
+	Push        *datafetcher.PushIngest
 	DataBuffer  []map[string]domain.ExchangeData
+	candles     *candleBuilder
+	stream      *priceHub
 	ctx         context.Context
 	cancel      context.CancelFunc
 	wg          sync.WaitGroup
 	mu          sync.Mutex
 }
 
-func NewDataFetcher(dataSource domain.DataFetcher, DataSaver domain.Database, Cache domain.CacheMemory) *DataModeServiceImp {
+func NewDataFetcher(dataSource domain.DataFetcher, DataSaver domain.Database, Cache domain.CacheMemory, Recorder domain.TickRecorder, Quarantine domain.TickQuarantine, Symbols domain.SymbolRegistry, Push *datafetcher.PushIngest) *DataModeServiceImp {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &DataModeServiceImp{
 		Datafetcher: dataSource,
 		DB:          DataSaver,
 		Cache:       Cache,
+		Recorder:    Recorder,
+		Quarantine:  Quarantine,
+		Symbols:     Symbols,
+		Push:        Push,
 		DataBuffer:  make([]map[string]domain.ExchangeData, 0),
+		candles:     newCandleBuilder(),
+		stream:      newPriceHub(),
 		ctx:         ctx,
 		cancel:      cancel,
 	}
@@ -42,20 +55,27 @@ func (serv *DataModeServiceImp) SwitchMode(mode string) (int, error) {
 	defer serv.mu.Unlock()
 
 	// Check if is current datafetcher mode equal to changing mode
-	if _, ok := serv.Datafetcher.(*datafetcher.LiveMode); (ok && mode == "live") || (!ok && mode == "test") {
+	if currentMode(serv.Datafetcher) == mode {
 		return http.StatusBadRequest, fmt.Errorf("data mode is already switched to %s", mode)
 	}
 
 	switch mode {
 	case "test":
+		// Default scenario from TEST_SCENARIO
+		scenario, err := datafetcher.LoadEnvScenario(serv.Symbols)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		return serv.startTestMode(scenario)
+	case "live":
 		serv.Datafetcher.Close()
-		serv.Datafetcher = datafetcher.NewTestModeFetcher()
+		serv.Datafetcher = datafetcher.NewLiveModeFetcher(serv.Recorder, serv.Quarantine, serv.Symbols, serv.Push)
 		if err := serv.ListenAndSave(); err != nil {
 			return http.StatusInternalServerError, err
 		}
-	case "live":
+	case "hybrid":
 		serv.Datafetcher.Close()
-		serv.Datafetcher = datafetcher.NewLiveModeFetcher()
+		serv.Datafetcher = datafetcher.NewHybridModeFetcher(serv.Recorder, serv.Quarantine, serv.Symbols, serv.Push)
 		if err := serv.ListenAndSave(); err != nil {
 			return http.StatusInternalServerError, err
 		}
@@ -65,6 +85,71 @@ func (serv *DataModeServiceImp) SwitchMode(mode string) (int, error) {
 	return http.StatusOK, nil
 }
 
+// Switches datafetcher to the test mode which executes scenario file, running test mode is restarted
+func (serv *DataModeServiceImp) SwitchToTestScenario(file string) (int, error) {
+	path, err := datafetcher.ResolveScenarioFile(file)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	scenario, err := datafetcher.LoadScenario(path, serv.Symbols)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	serv.mu.Lock()
+	defer serv.mu.Unlock()
+	return serv.startTestMode(scenario)
+}
+
+// Replaces datafetcher with test mode, serv.mu must be locked
+func (serv *DataModeServiceImp) startTestMode(scenario *datafetcher.Scenario) (int, error) {
+	serv.Datafetcher.Close()
+	serv.Datafetcher = datafetcher.NewTestModeFetcher(scenario, serv.Quarantine, serv.Symbols, serv.Push)
+	if err := serv.ListenAndSave(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Switches datafetcher to the playback of recorded ticks file
+func (serv *DataModeServiceImp) SwitchToReplayMode(file, speed string) (int, error) {
+	path, err := datafetcher.ResolveReplayFile(file)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	replaySpeed, err := datafetcher.ParseReplaySpeed(speed)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	serv.mu.Lock()
+	defer serv.mu.Unlock()
+
+	serv.Datafetcher.Close()
+	serv.Datafetcher = datafetcher.NewReplayModeFetcher(path, replaySpeed, serv.Quarantine, serv.Symbols, serv.Push)
+	if err := serv.ListenAndSave(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Returns name of the datafetcher mode
+func currentMode(fetcher domain.DataFetcher) string {
+	switch fetcher.(type) {
+	case *datafetcher.LiveMode:
+		return "live"
+	case *datafetcher.TestMode:
+		return "test"
+	case *datafetcher.HybridMode:
+		return "hybrid"
+	case *datafetcher.ReplayMode:
+		return "replay"
+	}
+	return ""
+}
+
 // Goroutines stop logic
 func (serv *DataModeServiceImp) StopListening() {
 	serv.cancel()
@@ -94,12 +179,16 @@ func (serv *DataModeServiceImp) ListenAndSave() error {
 		for {
 			select {
 			case <-serv.ctx.Done():
+				serv.saveCandles(true)
 				return
 			case <-t.C:
+				serv.saveCandles(false)
 				serv.mu.Lock()
-				merged := MergeAggregatedData(serv.DataBuffer)
-				serv.DB.SaveAggregatedData(merged)
-				serv.Cache.SaveAggregatedData(merged)
+				// One row per event-time minute, so replayed or lagging data keeps its own minutes
+				for _, merged := range MergeAggregatedDataByMinute(serv.DataBuffer) {
+					serv.DB.SaveAggregatedData(merged)
+					serv.Cache.SaveAggregatedData(merged)
+				}
 				serv.DataBuffer = nil
 				serv.mu.Unlock()
 			}
@@ -121,6 +210,7 @@ func (serv *DataModeServiceImp) ListenAndSave() error {
 				if !ok {
 					return
 				}
+				serv.publishAggregates(data)
 				serv.mu.Lock()
 				// To not overload buffer
 				// if len(serv.DataBuffer) > 15000 {
@@ -138,9 +228,16 @@ func (serv *DataModeServiceImp) ListenAndSave() error {
 // Retrieves the latest data from the channel and stores it in both PostgreSQL and Redis
 func (serv *DataModeServiceImp) SaveLatestData(rawDataCh chan []domain.Data) {
 	for rawData := range rawDataCh {
+		active := serv.activeTicks(rawData)
+		changed := serv.candles.Add(active, time.Now(), serv.stream.active())
+		serv.publishTicks(active)
+		serv.publishCandles(changed)
+
 		latestData := make(map[string]domain.Data)
+		maxLatest := len(domain.Exchanges) * len(serv.Symbols.List())
 		for i := len(rawData) - 1; i >= 0; i-- {
-			if rawData[i].ExchangeName == "" || rawData[i].Symbol == "" {
+			// Symbol could be retired while the batch was in the pipeline
+			if rawData[i].ExchangeName == "" || !serv.Symbols.Active(rawData[i].Symbol) {
 				continue
 			}
 
@@ -155,8 +252,6 @@ func (serv *DataModeServiceImp) SaveLatestData(rawDataCh chan []domain.Data) {
 				latestData[allKey] = rawData[i]
 			}
 
-			maxLatest := len(domain.Exchanges) * len(domain.Symbols)
-
 			// Break loop if we find all latest prices
 			if len(latestData) == maxLatest {
 				break
@@ -174,11 +269,20 @@ func (serv *DataModeServiceImp) SaveLatestData(rawDataCh chan []domain.Data) {
 	}
 }
 
+// Returns ticks of the active symbols, symbol could be retired while the batch was in the pipeline
+func (serv *DataModeServiceImp) activeTicks(ticks []domain.Data) []domain.Data {
+	active := make([]domain.Data, 0, len(ticks))
+	for _, tick := range ticks {
+		if tick.ExchangeName != "" && serv.Symbols.Active(tick.Symbol) {
+			active = append(active, tick)
+		}
+	}
+	return active
+}
+
 // Merges multiple aggregated exchange data entries into a single aggregated result
 func MergeAggregatedData(DataBuffer []map[string]domain.ExchangeData) map[string]domain.ExchangeData {
 	result := make(map[string]domain.ExchangeData)
-	sums := make(map[string]float64)
-	counts := make(map[string]int)
 
 	for _, dataMap := range DataBuffer {
 		for key, val := range dataMap {
@@ -199,9 +303,21 @@ func MergeAggregatedData(DataBuffer []map[string]domain.ExchangeData) map[string
 			if val.Max_price > agg.Max_price {
 				agg.Max_price = val.Max_price
 			}
-
-			sums[key] += val.Average_price
-			counts[key]++
+			agg.Origin = domain.MergeOrigin(agg.Origin, val.Origin)
+
+			// Average price is counted from the tick sums, not from the window averages
+			avg := val.Average()
+			agg.Price_sum += avg.Sum
+			agg.Tick_count += avg.Count
+			agg.Weighted_sum += avg.WeightedSum
+			agg.Weighted_time += avg.WeightedTime
+
+			// Bid and ask are counted from the quote sums too, windows without quotes don't lower them
+			agg.Volume += val.Volume
+			bid, ask, quotes := val.Quotes()
+			agg.Bid_sum += bid
+			agg.Ask_sum += ask
+			agg.Quote_count += quotes
 
 			if val.Timestamp.After(agg.Timestamp) {
 				agg.Timestamp = val.Timestamp
@@ -213,14 +329,42 @@ func MergeAggregatedData(DataBuffer []map[string]domain.ExchangeData) map[string
 
 	// Count average
 	for key, item := range result {
-		if count := counts[key]; count > 0 {
-			item.Average_price = sums[key] / float64(count)
+		if item.Tick_count > 0 {
+			item.Average_price = item.Price_sum / float64(item.Tick_count)
+			if item.Quote_count > 0 {
+				item.Average_bid = item.Bid_sum / float64(item.Quote_count)
+				item.Average_ask = item.Ask_sum / float64(item.Quote_count)
+			}
 			result[key] = item
 		}
 	}
 	return result
 }
 
+// Merges aggregated windows separately for every minute of their timestamps, result is ordered by minute
+func MergeAggregatedDataByMinute(DataBuffer []map[string]domain.ExchangeData) []map[string]domain.ExchangeData {
+	byMinute := make(map[time.Time][]map[string]domain.ExchangeData)
+	for _, dataMap := range DataBuffer {
+		for _, val := range dataMap {
+			minute := val.Timestamp.Truncate(time.Minute)
+			byMinute[minute] = append(byMinute[minute], dataMap)
+			break // all aggregates of a window share the timestamp
+		}
+	}
+
+	minutes := make([]time.Time, 0, len(byMinute))
+	for minute := range byMinute {
+		minutes = append(minutes, minute)
+	}
+	sort.Slice(minutes, func(i, j int) bool { return minutes[i].Before(minutes[j]) })
+
+	result := make([]map[string]domain.ExchangeData, 0, len(minutes))
+	for _, minute := range minutes {
+		result = append(result, MergeAggregatedData(byMinute[minute]))
+	}
+	return result
+}
+
 // Fetches aggregated market data for a specific exchange and symbol within a time period
 func (serv *DataModeServiceImp) GetAggregatedDataByDuration(exchange, symbol string, duration time.Duration) []map[string]domain.ExchangeData {
 	serv.mu.Lock()
@@ -254,3 +398,41 @@ func (serv *DataModeServiceImp) GetAggregatedDataByDuration(exchange, symbol str
 
 	return latest
 }
+
+// Returns aggregated data of the buffer which window starts within [from, to)
+func (serv *DataModeServiceImp) aggregatedDataInRange(exchange, symbol string, from, to time.Time) []map[string]domain.ExchangeData {
+	serv.mu.Lock()
+	defer serv.mu.Unlock()
+
+	var inRange []map[string]domain.ExchangeData
+	for _, m := range serv.DataBuffer {
+		data, ok := m[exchange+" "+symbol]
+		if ok && !data.Timestamp.Before(from) && data.Timestamp.Before(to) {
+			inRange = append(inRange, m)
+		}
+	}
+	return inRange
+}
+
+// ParseTimeRange parses from and to query values, empty from is the beginning of the data and empty to is now
+func ParseTimeRange(from, to string) (time.Time, time.Time, error) {
+	start, end := time.UnixMilli(0), time.Now()
+	if from != "" {
+		t, err := ParseTime(from)
+		if err != nil {
+			return start, end, fmt.Errorf("from: %w", err)
+		}
+		start = t
+	}
+	if to != "" {
+		t, err := ParseTime(to)
+		if err != nil {
+			return start, end, fmt.Errorf("to: %w", err)
+		}
+		end = t
+	}
+	if !start.Before(end) {
+		return start, end, domain.ErrInvalidTimeRange
+	}
+	return start, end, nil
+}
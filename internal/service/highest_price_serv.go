@@ -18,7 +18,7 @@ func (serv *DataModeServiceImp) GetHighestPrice(exchange, symbol string) (domain
 		return domain.Data{}, http.StatusBadRequest, err
 	}
 
-	if err := CheckSymbolName(symbol); err != nil {
+	if err := serv.CheckSymbolName(symbol); err != nil {
 		return domain.Data{}, http.StatusBadRequest, err
 	}
 
@@ -46,6 +46,7 @@ func (serv *DataModeServiceImp) GetHighestPrice(exchange, symbol string) (domain
 	if agg, ok := merged[key]; ok {
 		if agg.Max_price > highest.Price {
 			highest.Price = agg.Max_price
+			highest.Origin = agg.Origin
 			highest.Timestamp = agg.Timestamp.UnixMilli()
 		}
 	} else {
@@ -65,7 +66,7 @@ func (serv *DataModeServiceImp) GetHighestPriceWithPeriod(exchange, symbol strin
 		return domain.Data{}, http.StatusBadRequest, err
 	}
 
-	if err := CheckSymbolName(symbol); err != nil {
+	if err := serv.CheckSymbolName(symbol); err != nil {
 		return domain.Data{}, http.StatusBadRequest, err
 	}
 
@@ -80,7 +81,7 @@ func (serv *DataModeServiceImp) GetHighestPriceWithPeriod(exchange, symbol strin
 
 	startTime := time.Now()
 
-	highest, err := serv.DB.GetMaxPriceByExchangeWithDuration(exchange, symbol, startTime, duration)
+	highest, err := serv.DB.GetMaxPriceByExchangeInRange(exchange, symbol, startTime.Add(-duration), startTime)
 	if err != nil {
 		slog.Error("Failed to get highest price from Exchange by period", "error", err.Error())
 		return domain.Data{}, http.StatusInternalServerError, err
@@ -93,6 +94,7 @@ func (serv *DataModeServiceImp) GetHighestPriceWithPeriod(exchange, symbol strin
 	if agg, ok := merged[key]; ok {
 		if agg.Max_price > highest.Price {
 			highest.Price = agg.Max_price
+			highest.Origin = agg.Origin
 			highest.Timestamp = agg.Timestamp.UnixMilli()
 		}
 	} else {
@@ -110,7 +112,7 @@ func (serv *DataModeServiceImp) GetHighestPriceWithPeriod(exchange, symbol strin
 // Fetches the average price across all exchanges for a given symbol over a specified period
 func (serv *DataModeServiceImp) GetHighestPriceByAllExchangesWithPeriod(symbol string, period string) (domain.Data, int, error) {
 	exchange := "All"
-	if err := CheckSymbolName(symbol); err != nil {
+	if err := serv.CheckSymbolName(symbol); err != nil {
 		return domain.Data{}, http.StatusBadRequest, err
 	}
 
@@ -121,7 +123,7 @@ func (serv *DataModeServiceImp) GetHighestPriceByAllExchangesWithPeriod(symbol s
 
 	startTime := time.Now()
 
-	highest, err := serv.DB.GetMaxPriceByAllExchangesWithDuration(symbol, startTime, duration)
+	highest, err := serv.DB.GetMaxPriceByAllExchangesInRange(symbol, startTime.Add(-duration), startTime)
 	if err != nil {
 		slog.Error("Failed to get highest price from Exchange by period", "error", err.Error())
 		return domain.Data{}, http.StatusInternalServerError, err
@@ -134,6 +136,7 @@ func (serv *DataModeServiceImp) GetHighestPriceByAllExchangesWithPeriod(symbol s
 	if agg, ok := merged[key]; ok {
 		if agg.Max_price > highest.Price {
 			highest.Price = agg.Max_price
+			highest.Origin = agg.Origin
 			highest.Timestamp = agg.Timestamp.UnixMilli()
 		}
 	} else {
@@ -146,3 +149,46 @@ func (serv *DataModeServiceImp) GetHighestPriceByAllExchangesWithPeriod(symbol s
 
 	return highest, http.StatusOK, nil
 }
+
+// Fetches the highest price of the exchange (or All) and symbol within [from, to), RFC3339 times or unix milliseconds
+func (serv *DataModeServiceImp) GetHighestPriceInRange(exchange, symbol, from, to string) (domain.Data, int, error) {
+	if err := CheckExchangeName(exchange); err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+
+	if err := serv.CheckSymbolName(symbol); err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+
+	start, end, err := ParseTimeRange(from, to)
+	if err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+
+	var highest domain.Data
+	switch exchange {
+	case "All":
+		highest, err = serv.DB.GetMaxPriceByAllExchangesInRange(symbol, start, end)
+	default:
+		highest, err = serv.DB.GetMaxPriceByExchangeInRange(exchange, symbol, start, end)
+	}
+	if err != nil {
+		slog.Error("Failed to get highest price by time range", "error", err.Error())
+		return domain.Data{}, http.StatusInternalServerError, err
+	}
+
+	merged := MergeAggregatedData(serv.aggregatedDataInRange(exchange, symbol, start, end))
+
+	key := exchange + " " + symbol
+	if agg, ok := merged[key]; ok && agg.Max_price > highest.Price {
+		highest.Price = agg.Max_price
+		highest.Origin = agg.Origin
+		highest.Timestamp = agg.Timestamp.UnixMilli()
+	}
+
+	if highest.Price == 0 {
+		return domain.Data{}, http.StatusNotFound, domain.ErrHighPriceWithPeriodNotFound
+	}
+
+	return highest, http.StatusOK, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"encoding/base64"
+	"fmt"
+	"marketflow/internal/domain"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Default and maximum number of points of one history page
+	defaultHistoryPage = 100
+	maxHistoryPage     = 1000
+)
+
+// Returns a page of the stored aggregated rows of the exchange (or All) and symbol within [from, to)
+//
+// From and to are RFC3339 times or unix milliseconds (default all stored rows), page size is 1-1000 (default 100).
+// Page token is taken from the previous page, it keeps the position so pages don't repeat rows. Rows are ordered by
+// their window time, so a row stored meanwhile with a time before the position (a late window) is skipped
+func (serv *DataModeServiceImp) GetPriceHistory(exchange, symbol, from, to, pageToken, pageSize string) (domain.PriceHistory, int, error) {
+	if err := CheckExchangeName(exchange); err != nil {
+		return domain.PriceHistory{}, http.StatusBadRequest, err
+	}
+	if err := serv.CheckSymbolName(symbol); err != nil {
+		return domain.PriceHistory{}, http.StatusBadRequest, err
+	}
+
+	start, end, err := ParseTimeRange(from, to)
+	if err != nil {
+		return domain.PriceHistory{}, http.StatusBadRequest, err
+	}
+
+	limit := defaultHistoryPage
+	if pageSize != "" {
+		limit, err = strconv.Atoi(pageSize)
+		if err != nil || limit < 1 || limit > maxHistoryPage {
+			return domain.PriceHistory{}, http.StatusBadRequest, domain.ErrInvalidPageSize
+		}
+	}
+
+	// Without token the page starts at from, row ids are positive so rows stored exactly at from are included
+	after := domain.PricePoint{Timestamp: start}
+	if pageToken != "" {
+		after, err = decodePageToken(pageToken)
+		if err != nil {
+			return domain.PriceHistory{}, http.StatusBadRequest, err
+		}
+	}
+
+	// One more point shows if there is the next page
+	points, err := serv.DB.GetPriceHistory(exchange, symbol, start, end, after, limit+1)
+	if err != nil {
+		return domain.PriceHistory{}, http.StatusInternalServerError, err
+	}
+
+	history := domain.PriceHistory{Exchange: exchange, Symbol: symbol, Points: points}
+	if len(points) > limit {
+		history.Points = points[:limit]
+		history.NextPageToken = encodePageToken(points[limit-1])
+	}
+	return history, http.StatusOK, nil
+}
+
+// Page token is the position of the last point of the page, "<unix microseconds>.<row id>" in base64
+func encodePageToken(last domain.PricePoint) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d.%d", last.Timestamp.UnixMicro(), last.ID))
+}
+
+func decodePageToken(token string) (domain.PricePoint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return domain.PricePoint{}, domain.ErrInvalidPageToken
+	}
+
+	micro, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return domain.PricePoint{}, domain.ErrInvalidPageToken
+	}
+	us, err := strconv.ParseInt(micro, 10, 64)
+	if err != nil {
+		return domain.PricePoint{}, domain.ErrInvalidPageToken
+	}
+	rowID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || rowID < 1 {
+		return domain.PricePoint{}, domain.ErrInvalidPageToken
+	}
+	return domain.PricePoint{ID: rowID, Timestamp: time.UnixMicro(us)}, nil
+}
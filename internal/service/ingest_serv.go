@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"marketflow/internal/domain"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Passes ticks pushed by the source into the ingest pipeline of the current mode, returns the number of accepted ticks
+//
+// The source name is the exchange of the ticks, ticks without timestamp get the receive time.
+// Prices, quotes and symbols are checked by the pipeline validator like ticks of the exchanges
+func (serv *DataModeServiceImp) IngestTicks(source string, ticks []domain.Data) (int, int, error) {
+	if len(ticks) == 0 {
+		return 0, http.StatusBadRequest, domain.ErrEmptyIngestBatch
+	}
+
+	now := time.Now().UnixMilli()
+	batch := make([]domain.Data, 0, len(ticks))
+	for i, tick := range ticks {
+		tick.Symbol = strings.ToUpper(strings.TrimSpace(tick.Symbol))
+		if tick.Symbol == "" {
+			return 0, http.StatusBadRequest, fmt.Errorf("tick %d: %w", i+1, domain.ErrEmptySymbolVal)
+		}
+		if tick.Timestamp == 0 {
+			tick.Timestamp = now
+		}
+		tick.ExchangeName = source
+		tick.Origin = domain.OriginLive
+		batch = append(batch, tick)
+	}
+
+	if serv.Push == nil {
+		return 0, http.StatusServiceUnavailable, domain.ErrIngestBusy
+	}
+
+	switch err := serv.Push.Push(source, batch); err {
+	case nil:
+		return len(batch), http.StatusAccepted, nil
+	case domain.ErrIngestBatchTooLarge:
+		return 0, http.StatusRequestEntityTooLarge, err
+	case domain.ErrIngestRateLimited:
+		return 0, http.StatusTooManyRequests, err
+	default:
+		return 0, http.StatusServiceUnavailable, err
+	}
+}
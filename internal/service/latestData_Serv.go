@@ -18,7 +18,7 @@ func (serv *DataModeServiceImp) GetLatestData(exchange string, symbol string) (d
 		return latest, http.StatusBadRequest, err
 	}
 
-	if err := CheckSymbolName(symbol); err != nil {
+	if err := serv.CheckSymbolName(symbol); err != nil {
 		slog.Error("Failed to get latest data: ", "error", err.Error())
 		return latest, http.StatusBadRequest, err
 	}
@@ -13,7 +13,7 @@ func (serv *DataModeServiceImp) GetLowestPrice(exchange, symbol string) (domain.
 		return domain.Data{}, http.StatusBadRequest, err
 	}
 
-	if err := CheckSymbolName(symbol); err != nil {
+	if err := serv.CheckSymbolName(symbol); err != nil {
 		return domain.Data{}, http.StatusBadRequest, err
 	}
 
@@ -44,6 +44,7 @@ func (serv *DataModeServiceImp) GetLowestPrice(exchange, symbol string) (domain.
 	if agg, ok := merged[key]; ok {
 		if lowest.Price == 0 || lowest.Price > agg.Min_price {
 			lowest.Price = agg.Min_price
+			lowest.Origin = agg.Origin
 			lowest.Timestamp = agg.Timestamp.UnixMilli()
 		}
 	} else {
@@ -63,7 +64,7 @@ func (serv *DataModeServiceImp) GetLowestPriceWithPeriod(exchange, symbol string
 		return domain.Data{}, http.StatusBadRequest, err
 	}
 
-	if err := CheckSymbolName(symbol); err != nil {
+	if err := serv.CheckSymbolName(symbol); err != nil {
 		return domain.Data{}, http.StatusBadRequest, err
 	}
 
@@ -78,7 +79,7 @@ func (serv *DataModeServiceImp) GetLowestPriceWithPeriod(exchange, symbol string
 
 	startTime := time.Now()
 
-	lowest, err := serv.DB.GetMinPriceByExchangeWithDuration(exchange, symbol, startTime, duration)
+	lowest, err := serv.DB.GetMinPriceByExchangeInRange(exchange, symbol, startTime.Add(-duration), startTime)
 	if err != nil {
 		slog.Error("Failed to get lowest price from Exchange by period", "error", err.Error())
 		return domain.Data{}, http.StatusInternalServerError, err
@@ -91,6 +92,7 @@ func (serv *DataModeServiceImp) GetLowestPriceWithPeriod(exchange, symbol string
 	if agg, ok := merged[key]; ok {
 		if lowest.Price == 0 || lowest.Price > agg.Min_price {
 			lowest.Price = agg.Min_price
+			lowest.Origin = agg.Origin
 			lowest.Timestamp = agg.Timestamp.UnixMilli()
 		}
 	} else {
@@ -107,7 +109,7 @@ func (serv *DataModeServiceImp) GetLowestPriceWithPeriod(exchange, symbol string
 // Fetches the lowest price across all exchanges for a given symbol over a specified period
 func (serv *DataModeServiceImp) GetLowestPriceByAllExchangesWithPeriod(symbol string, period string) (domain.Data, int, error) {
 	exchange := "All"
-	if err := CheckSymbolName(symbol); err != nil {
+	if err := serv.CheckSymbolName(symbol); err != nil {
 		return domain.Data{}, http.StatusBadRequest, err
 	}
 
@@ -118,7 +120,7 @@ func (serv *DataModeServiceImp) GetLowestPriceByAllExchangesWithPeriod(symbol st
 
 	startTime := time.Now()
 
-	lowest, err := serv.DB.GetMinPriceByAllExchangesWithDuration(symbol, startTime, duration)
+	lowest, err := serv.DB.GetMinPriceByAllExchangesInRange(symbol, startTime.Add(-duration), startTime)
 	if err != nil {
 		slog.Error("Failed to get lowest price from Exchange by period", "error", err.Error())
 		return domain.Data{}, http.StatusInternalServerError, err
@@ -131,6 +133,7 @@ func (serv *DataModeServiceImp) GetLowestPriceByAllExchangesWithPeriod(symbol st
 	if agg, ok := merged[key]; ok {
 		if lowest.Price == 0 || lowest.Price > agg.Min_price {
 			lowest.Price = agg.Min_price
+			lowest.Origin = agg.Origin
 			lowest.Timestamp = agg.Timestamp.UnixMilli()
 		}
 	} else {
@@ -143,3 +146,46 @@ func (serv *DataModeServiceImp) GetLowestPriceByAllExchangesWithPeriod(symbol st
 
 	return lowest, http.StatusOK, nil
 }
+
+// Fetches the lowest price of the exchange (or All) and symbol within [from, to), RFC3339 times or unix milliseconds
+func (serv *DataModeServiceImp) GetLowestPriceInRange(exchange, symbol, from, to string) (domain.Data, int, error) {
+	if err := CheckExchangeName(exchange); err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+
+	if err := serv.CheckSymbolName(symbol); err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+
+	start, end, err := ParseTimeRange(from, to)
+	if err != nil {
+		return domain.Data{}, http.StatusBadRequest, err
+	}
+
+	var lowest domain.Data
+	switch exchange {
+	case "All":
+		lowest, err = serv.DB.GetMinPriceByAllExchangesInRange(symbol, start, end)
+	default:
+		lowest, err = serv.DB.GetMinPriceByExchangeInRange(exchange, symbol, start, end)
+	}
+	if err != nil {
+		slog.Error("Failed to get lowest price by time range", "error", err.Error())
+		return domain.Data{}, http.StatusInternalServerError, err
+	}
+
+	merged := MergeAggregatedData(serv.aggregatedDataInRange(exchange, symbol, start, end))
+
+	key := exchange + " " + symbol
+	if agg, ok := merged[key]; ok && (lowest.Price == 0 || lowest.Price > agg.Min_price) {
+		lowest.Price = agg.Min_price
+		lowest.Origin = agg.Origin
+		lowest.Timestamp = agg.Timestamp.UnixMilli()
+	}
+
+	if lowest.Price == 0 {
+		return domain.Data{}, http.StatusNotFound, domain.ErrLowestPriceWithPeriodNotFound
+	}
+
+	return lowest, http.StatusOK, nil
+}
@@ -0,0 +1,8 @@
+package service
+
+import "marketflow/internal/domain"
+
+// Returns counters and the newest ticks removed from the ingest pipeline
+func (serv *DataModeServiceImp) QuarantineReport(reason string, limit int) domain.QuarantineReport {
+	return serv.Quarantine.Report(reason, limit)
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"fmt"
+	"marketflow/internal/domain"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// Events kept for a Server-Sent Events subscriber which is busy writing, newer events are dropped when it is full
+	subscriberBuffer = 256
+	// Channels of one WebSocket connection
+	maxClientChannels = 100
+)
+
+// Fans out live price events to the stream subscribers, publishing never blocks the ingest pipeline
+type priceHub struct {
+	mu   sync.RWMutex
+	subs map[*priceSubscriber]struct{}
+}
+
+func newPriceHub() *priceHub {
+	return &priceHub{subs: make(map[*priceSubscriber]struct{})}
+}
+
+type priceSubscriber struct {
+	hub        *priceHub
+	match      func(domain.PriceEvent) bool
+	events     chan domain.PriceEvent
+	disconnect bool // slow subscriber is closed instead of dropping events
+	dropped    atomic.Int64
+	overflowed atomic.Bool
+	closed     bool // guarded by hub.mu
+}
+
+// Sends the event to the matching subscribers, full subscribers lose the event or are closed
+func (h *priceHub) publish(ev domain.PriceEvent) {
+	var slow []*priceSubscriber
+
+	h.mu.RLock()
+	for sub := range h.subs {
+		if !sub.match(ev) {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			sub.dropped.Add(1)
+			if sub.disconnect && !sub.overflowed.Swap(true) {
+				slow = append(slow, sub)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	if len(slow) == 0 {
+		return
+	}
+	h.mu.Lock()
+	for _, sub := range slow {
+		sub.close()
+	}
+	h.mu.Unlock()
+}
+
+// Reports if anybody listens, so events are not built for nothing
+func (h *priceHub) active() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs) > 0
+}
+
+func (h *priceHub) subscribe(match func(domain.PriceEvent) bool, buffer int, disconnect bool) *priceSubscriber {
+	sub := &priceSubscriber{hub: h, match: match, events: make(chan domain.PriceEvent, buffer), disconnect: disconnect}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Closes every subscription, used on shutdown so the streaming requests could finish
+func (h *priceHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		sub.close()
+	}
+}
+
+// hub.mu must be locked
+func (sub *priceSubscriber) close() {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(sub.hub.subs, sub)
+	close(sub.events)
+}
+
+func (sub *priceSubscriber) Events() <-chan domain.PriceEvent { return sub.events }
+
+func (sub *priceSubscriber) Dropped() int64 { return sub.dropped.Load() }
+
+func (sub *priceSubscriber) Overflowed() bool { return sub.overflowed.Load() }
+
+func (sub *priceSubscriber) Close() {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+	sub.close()
+}
+
+func matchFilter(filter domain.StreamFilter) func(domain.PriceEvent) bool {
+	return func(ev domain.PriceEvent) bool {
+		return matchAny(filter.Events, ev.Type) &&
+			matchAny(filter.Exchanges, ev.Exchange) &&
+			matchAny(filter.Symbols, ev.Symbol)
+	}
+}
+
+func matchAny(list []string, val string) bool {
+	return len(list) == 0 || slices.Contains(list, val)
+}
+
+// Subscription of the WebSocket API, the events of the subscribed channels are delivered
+type channelSubscriber struct {
+	*priceSubscriber
+	serv *DataModeServiceImp
+
+	mu       sync.RWMutex
+	channels map[string]struct{}
+}
+
+func (sub *channelSubscriber) match(ev domain.PriceEvent) bool {
+	sub.mu.RLock()
+	defer sub.mu.RUnlock()
+
+	for _, channel := range ev.Channels() {
+		if _, ok := sub.channels[channel]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (sub *channelSubscriber) Subscribe(channels []string) error {
+	for _, name := range channels {
+		channel, err := domain.ParseChannel(name)
+		if err != nil {
+			return err
+		}
+		if err := CheckExchangeName(channel.Exchange); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := sub.serv.CheckSymbolName(channel.Symbol); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	added := 0
+	for _, name := range channels {
+		if _, ok := sub.channels[name]; !ok {
+			added++
+		}
+	}
+	if len(sub.channels)+added > maxClientChannels {
+		return domain.ErrTooManyChannels
+	}
+	for _, name := range channels {
+		sub.channels[name] = struct{}{}
+	}
+	return nil
+}
+
+func (sub *channelSubscriber) Unsubscribe(channels []string) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	for _, name := range channels {
+		delete(sub.channels, name)
+	}
+}
+
+func (sub *channelSubscriber) Channels() []string {
+	sub.mu.RLock()
+	defer sub.mu.RUnlock()
+
+	channels := make([]string, 0, len(sub.channels))
+	for name := range sub.channels {
+		channels = append(channels, name)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+func (sub *channelSubscriber) Has(channel string) bool {
+	sub.mu.RLock()
+	defer sub.mu.RUnlock()
+
+	_, ok := sub.channels[channel]
+	return ok
+}
+
+// Subscribes to live price events, symbols and exchanges (All included) are validated, empty lists match everything
+func (serv *DataModeServiceImp) SubscribePrices(filter domain.StreamFilter) (domain.PriceSubscription, int, error) {
+	for i, symbol := range filter.Symbols {
+		symbol = strings.ToUpper(symbol)
+		if err := serv.CheckSymbolName(symbol); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		filter.Symbols[i] = symbol
+	}
+	for _, exchange := range filter.Exchanges {
+		if err := CheckExchangeName(exchange); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	for _, event := range filter.Events {
+		if !slices.Contains(domain.StreamEvents, event) {
+			return nil, http.StatusBadRequest, domain.ErrInvalidStreamEvent
+		}
+	}
+
+	return serv.stream.subscribe(matchFilter(filter), subscriberBuffer, false), http.StatusOK, nil
+}
+
+// Opens a subscription without channels, buffer bounds the events waiting for the client and
+// slowClient (drop or disconnect) tells what happens when it is full
+func (serv *DataModeServiceImp) SubscribeChannels(buffer int, slowClient string) domain.ChannelSubscription {
+	sub := &channelSubscriber{serv: serv, channels: make(map[string]struct{})}
+	sub.priceSubscriber = serv.stream.subscribe(sub.match, buffer, slowClient == domain.SlowClientDisconnect)
+	return sub
+}
+
+// Closes all price streams
+func (serv *DataModeServiceImp) CloseStreams() {
+	serv.stream.closeAll()
+}
+
+// Publishes accepted ticks to the price streams
+func (serv *DataModeServiceImp) publishTicks(ticks []domain.Data) {
+	if !serv.stream.active() {
+		return
+	}
+	for i := range ticks {
+		tick := ticks[i]
+		serv.stream.publish(domain.PriceEvent{Type: domain.EventTick, Exchange: tick.ExchangeName, Symbol: tick.Symbol, Tick: &tick})
+	}
+}
+
+// Publishes closed aggregation windows to the price streams
+func (serv *DataModeServiceImp) publishAggregates(aggregated map[string]domain.ExchangeData) {
+	if !serv.stream.active() {
+		return
+	}
+	for _, agg := range aggregated {
+		if !serv.Symbols.Active(agg.Pair_name) {
+			continue
+		}
+		serv.stream.publish(domain.PriceEvent{Type: domain.EventAggregate, Exchange: agg.Exchange, Symbol: agg.Pair_name, Aggregate: &agg})
+	}
+}
+
+// Publishes candles changed by the ticks to the price streams
+func (serv *DataModeServiceImp) publishCandles(candles []domain.Candle) {
+	for _, candle := range candles {
+		serv.stream.publish(domain.PriceEvent{Type: domain.EventCandle, Exchange: candle.Exchange, Symbol: candle.Symbol, Interval: candle.Interval, Candle: &candle})
+	}
+}
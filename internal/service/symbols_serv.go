@@ -0,0 +1,42 @@
+package service
+
+import (
+	"log/slog"
+	"marketflow/internal/domain"
+	"net/http"
+	"strings"
+)
+
+// Returns active and retired symbols of the registry
+func (serv *DataModeServiceImp) ListSymbols() []domain.Symbol {
+	return serv.Symbols.All()
+}
+
+// Registers the symbol or activates the retired one, it is generated by test mode from the next batch
+func (serv *DataModeServiceImp) AddSymbol(symbol string) (domain.Symbol, int, error) {
+	added, err := serv.Symbols.Add(strings.ToUpper(symbol))
+	switch err {
+	case nil:
+		return added, http.StatusOK, nil
+	case domain.ErrInvalidSymbolName:
+		return added, http.StatusBadRequest, err
+	}
+	return added, http.StatusInternalServerError, err
+}
+
+// Retires the symbol: its ticks are rejected and the cached prices are removed, stored history is kept
+func (serv *DataModeServiceImp) RetireSymbol(symbol string) (domain.Symbol, int, error) {
+	retired, err := serv.Symbols.Retire(strings.ToUpper(symbol))
+	switch err {
+	case nil:
+	case domain.ErrSymbolNotFound:
+		return retired, http.StatusNotFound, err
+	default:
+		return retired, http.StatusInternalServerError, err
+	}
+
+	if err := serv.Cache.DeleteSymbol(retired.Name); err != nil {
+		slog.Warn("Failed to remove retired symbol from cache", "symbol", retired.Name, "error", err.Error())
+	}
+	return retired, http.StatusOK, nil
+}
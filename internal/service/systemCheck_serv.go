@@ -8,25 +8,55 @@ import (
 // Services health checking logic
 func (serv *DataModeServiceImp) CheckHealth() []domain.ConnMsg {
 	data := make([]domain.ConnMsg, 0)
+	healthy := true
 
-	if err := serv.Datafetcher.CheckHealth(); err != nil {
+	fetcher := serv.fetcher()
+	if err := fetcher.CheckHealth(); err != nil {
 		slog.Error("Cathed error from Datafetcher health: ", "error", err.Error())
 		data = append(data, domain.ConnMsg{Connection: "Datafetcher", Status: err.Error()})
+		healthy = false
+	}
+
+	// Every live exchange is reported with its connection state
+	for _, status := range fetcher.ExchangeStatuses() {
+		msg := domain.ConnMsg{Connection: status.Name, Status: string(status.State), Exchange: &status}
+		if status.Stale || len(status.StaleSymbols) != 0 {
+			msg.Status += " (stale)"
+		}
+		data = append(data, msg)
 	}
 
 	if err := serv.DB.CheckHealth(); err != nil {
 		slog.Info("Cathed error from Database health: ", "error", err.Error())
 		data = append(data, domain.ConnMsg{Connection: "Database", Status: "unhealthy"})
+		healthy = false
 	}
 
 	if err := serv.Cache.CheckHealth(); err != nil {
 		slog.Info("Cathed error from Cache health: ", "error", err.Error())
 		data = append(data, domain.ConnMsg{Connection: "Cache", Status: "unhealthy"})
+		healthy = false
 	}
 
-	if len(data) == 0 {
+	if healthy {
 		data = append(data, domain.ConnMsg{Status: "all connections are healthy"})
 	}
 
 	return data
 }
+
+// Returns connection reports of the live exchanges, empty in test and replay modes
+func (serv *DataModeServiceImp) ExchangeStatuses() []domain.ExchangeStatus {
+	statuses := serv.fetcher().ExchangeStatuses()
+	if statuses == nil {
+		return []domain.ExchangeStatus{}
+	}
+	return statuses
+}
+
+// Returns current datafetcher, it could be replaced by mode switch
+func (serv *DataModeServiceImp) fetcher() domain.DataFetcher {
+	serv.mu.Lock()
+	defer serv.mu.Unlock()
+	return serv.Datafetcher
+}
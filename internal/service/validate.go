@@ -13,11 +13,10 @@ func CheckExchangeName(exchange string) error {
 	return domain.ErrInvalidExchangeVal
 }
 
-func CheckSymbolName(symbol string) error {
-	for _, val := range domain.Symbols {
-		if symbol == val {
-			return nil
-		}
+// Symbols retired from the registry are valid, their history is still stored
+func (serv *DataModeServiceImp) CheckSymbolName(symbol string) error {
+	if serv.Symbols.Known(symbol) {
+		return nil
 	}
 	return domain.ErrInvalidSymbolVal
 }